@@ -0,0 +1,74 @@
+// Copyright © 2025 KoHorizon
+// Licensed under the MIT License.
+// See LICENSE file in the project root for full license information.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/KoHorizon/ForgeDir/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	convertTo     string
+	convertOutput string
+)
+
+var convertCmd = &cobra.Command{
+	Use:   "convert <spec|->",
+	Short: "Convert a spec between YAML, JSON and TOML",
+	Long: `Convert a spec between YAML, JSON and TOML.
+
+The spec is converted as written: includes and extends are kept, not merged.
+The input format comes from the file extension or --format, the output format
+from --to or the extension of --output, and defaults to YAML. Comments are not
+carried over.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfigFile(args[0], specFormat)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", args[0], err)
+		}
+
+		to := convertTo
+		if to == "" && convertOutput != "" {
+			to = config.FormatOf(convertOutput)
+		}
+		if to == "" {
+			to = config.FormatYAML
+		}
+		out, err := config.Encode(cfg, to)
+		if err != nil {
+			return fmt.Errorf("converting to %s: %w", to, err)
+		}
+
+		if convertOutput == "" {
+			fmt.Print(string(out))
+			return nil
+		}
+		if err := os.WriteFile(convertOutput, out, 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", convertOutput, err)
+		}
+		fmt.Printf("✅ Wrote %s spec '%s'\n", to, convertOutput)
+		return nil
+	},
+}
+
+func init() {
+	convertCmd.Flags().StringVar(
+		&convertTo, "to", "",
+		"output format: "+strings.Join(config.Formats, ", "),
+	)
+	convertCmd.Flags().StringVarP(
+		&convertOutput, "output", "o", "",
+		"file to write the converted spec to (default: standard output)",
+	)
+	addFormatFlag(convertCmd)
+
+	rootCmd.AddCommand(convertCmd)
+}
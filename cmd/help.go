@@ -25,6 +25,8 @@ Available Commands:
   help               Show help about the tool
   init               Read a YAML spec and scaffold the project
   validate           Validate that a spec.yaml is well-formed
+  import             Turn tree or find output, or a list of paths, into a spec
+  convert            Convert a spec between YAML, JSON and TOML
   list-templates     List the built-in templates (or those for a given language)
   version            Show the current version of the CLI
 
@@ -0,0 +1,117 @@
+// Copyright © 2025 KoHorizon
+// Licensed under the MIT License.
+// See LICENSE file in the project root for full license information.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/KoHorizon/ForgeDir/internal/config"
+	"github.com/KoHorizon/ForgeDir/internal/importer"
+	"github.com/goccy/go-yaml"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importFrom     string
+	importLanguage string
+	importName     string
+	importOutput   string
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Turn tree or find output, or a list of paths, into a spec",
+	Long: `Turn a layout sketched outside ForgeDir into a spec.
+
+<file> holds the output of 'tree' or 'find', or one path per line ('-' reads
+standard input). A trailing slash marks a directory; so does having entries
+below it. Other entries are files when they have an extension, start with a
+dot or are well-known names such as Makefile, and empty directories otherwise.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// The spec must name a language validate and init know
+		languages, err := availableTemplates()
+		if err != nil {
+			return err
+		}
+		if _, ok := languages[importLanguage]; !ok {
+			return fmt.Errorf("unknown language '%s' (available: %s)", importLanguage, languageList(languages))
+		}
+
+		var in io.Reader = os.Stdin
+		if args[0] != "-" {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("opening %s: %w", args[0], err)
+			}
+			defer f.Close()
+			in = f
+		}
+
+		result, err := importer.Import(in, importFrom)
+		if err != nil {
+			return fmt.Errorf("importing %s: %w", args[0], err)
+		}
+
+		// Project name: the flag, the listing's root, then the file name
+		name := importName
+		if name == "" {
+			name = result.Root
+		}
+		if name == "" && args[0] != "-" {
+			name = strings.TrimSuffix(filepath.Base(args[0]), filepath.Ext(args[0]))
+		}
+		if name == "" {
+			name = "my-project"
+		}
+
+		cfg := &config.Config{
+			ProjectName: name,
+			Language:    importLanguage,
+			Structure:   result.Structure,
+		}
+		out, err := yaml.MarshalWithOptions(cfg, yaml.IndentSequence(true))
+		if err != nil {
+			return fmt.Errorf("writing spec: %w", err)
+		}
+
+		if importOutput == "" {
+			fmt.Print(string(out))
+			return nil
+		}
+		if err := os.WriteFile(importOutput, out, 0644); err != nil {
+			return fmt.Errorf("writing spec: %w", err)
+		}
+		fmt.Printf("✅ Wrote spec '%s' (%d nodes)\n", importOutput, countNodes(cfg.Structure))
+		return nil
+	},
+}
+
+func init() {
+	importCmd.Flags().StringVar(
+		&importFrom, "from", importer.FormatTree,
+		"input format: "+strings.Join(importer.Formats, ", "),
+	)
+	importCmd.Flags().StringVarP(
+		&importLanguage, "language", "l", "",
+		"language of the generated spec (required)",
+	)
+	importCmd.MarkFlagRequired("language")
+	importCmd.Flags().StringVarP(
+		&importName, "name", "n", "",
+		"project name (default: the listing's root directory, or the file name)",
+	)
+	importCmd.Flags().StringVarP(
+		&importOutput, "output", "o", "",
+		"file to write the spec to (default: standard output)",
+	)
+
+	rootCmd.AddCommand(importCmd)
+}
@@ -5,8 +5,12 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
+	"runtime"
+	"sync"
 
 	"github.com/KoHorizon/ForgeDir/internal/builder"
 	"github.com/KoHorizon/ForgeDir/internal/config"
@@ -15,43 +19,69 @@ import (
 )
 
 var initCmd = &cobra.Command{
-	Use:   "init [spec.yaml]",
+	Use:   "init [spec.yaml|-]",
 	Short: "Read a YAML spec and scaffold the project",
 	Args:  cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if len(args) == 1 {
 			cfgFile = args[0]
 		}
+		if jobs < 1 {
+			return fmt.Errorf("--jobs must be at least 1, got %d", jobs)
+		}
 		outputDir, _ = filepath.Abs(outputDir)
 
 		// 1. Load config
-		cfg, err := config.LoadConfigFromYaml(cfgFile)
+		cfg, err := config.LoadWith(cfgFile, config.LoadOptions{Format: specFormat, Portability: portability})
+		if err != nil {
+			return fmt.Errorf("loading config %q: %w", cfgFile, err)
+		}
+		projects, err := cfg.Subprojects()
 		if err != nil {
 			return fmt.Errorf("loading config %q: %w", cfgFile, err)
 		}
 
-		// 2. Build file tree
-		fs := builder.NewOSFileSystem()
-		sb := builder.NewStructureBuilder(fs)
-		if err := sb.Build(cfg, outputDir); err != nil {
-			return fmt.Errorf("creating structure: %w", err)
+		// 2. Clear what an interrupted run left behind, in the directories
+		// the spec writes into
+		removed, err := removeTempFiles(cfg, projects, outputDir)
+		if err != nil {
+			return fmt.Errorf("removing stray temp files: %w", err)
+		}
+		if removed > 0 {
+			fmt.Printf("Removed %d stray temp file(s) from an interrupted run\n", removed)
 		}
 
-		// 3. Generate boilerplate
-		fmt.Printf("Generating boilerplate for %q in %s …\n", cfg.Language, outputDir)
+		// 3. Set up generators (shared by every project), recording what
+		// they write in case the run is interrupted
+		journal := builder.NewJournal(builder.NewOSFileSystem())
+		var fs builder.FileSystem = journal
 		templateSource, err := generator.CreateTemplateSource(templatesDir)
 		if err != nil {
 			return fmt.Errorf("setting up templates: %w", err)
 		}
 		factory := generator.NewGeneratorFactory(fs, templateSource)
+		factory.SetJobs(jobs)
 		generators, err := factory.CreateAvailableGenerators()
 		if err != nil {
 			return fmt.Errorf("creating generators: %w", err)
 		}
-
 		coord := generator.NewCoordinator(generators)
-		if err := coord.RunBoilerplateGeneration(cfg, outputDir); err != nil {
-			return fmt.Errorf("boilerplate generation failed: %w", err)
+
+		// 4. Shared root files, then every project
+		ctx := cmd.Context()
+		if len(cfg.Structure) > 0 || len(projects) == 0 {
+			err = scaffold(ctx, cfg, outputDir, fs, coord)
+		}
+		if err == nil {
+			err = scaffoldProjects(ctx, projects, outputDir, fs, coord)
+		}
+		if errors.Is(err, context.Canceled) {
+			cmd.SilenceUsage = true
+			reportInterrupted(journal.Entries())
+			return err
+		}
+		if err != nil {
+			return err
 		}
 
 		fmt.Println("✅ ForgeDir finished project generation.")
@@ -59,15 +89,116 @@ var initCmd = &cobra.Command{
 	},
 }
 
+// removeTempFiles clears the temp files an interrupted run left in the
+// directories of the spec and of each of its projects.
+func removeTempFiles(cfg *config.Config, projects []*config.Config, root string) (int, error) {
+	removed, err := builder.RemoveTempFiles(cfg, root)
+	if err != nil {
+		return removed, err
+	}
+	for _, p := range projects {
+		n, err := builder.RemoveTempFiles(p, filepath.Join(root, filepath.FromSlash(p.Output)))
+		removed += n
+		if err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+// scaffold builds the file tree of cfg under root and renders its boilerplate.
+// Folders get their modes last, so a read-only folder can still be filled.
+func scaffold(ctx context.Context, cfg *config.Config, root string, fs builder.FileSystem, coord *generator.Coordinator) error {
+	sb := builder.NewStructureBuilder(fs)
+	if err := sb.BuildWritable(ctx, cfg, root); err != nil {
+		return fmt.Errorf("creating structure: %w", err)
+	}
+
+	fmt.Printf("Generating boilerplate for %q in %s …\n", cfg.Language, root)
+	if err := coord.RunBoilerplateGeneration(ctx, cfg, root); err != nil {
+		return fmt.Errorf("boilerplate generation failed: %w", err)
+	}
+	if err := sb.ApplyFolderModes(ctx); err != nil {
+		return fmt.Errorf("setting folder modes: %w", err)
+	}
+	return nil
+}
+
+// scaffoldProjects generates every project of a monorepo spec. Projects whose
+// output directories are disjoint run in parallel; a project that overlaps one
+// already in the current batch starts a new batch, so the spec order decides
+// which one writes first.
+func scaffoldProjects(ctx context.Context, projects []*config.Config, root string, fs builder.FileSystem, coord *generator.Coordinator) error {
+	for start := 0; start < len(projects); {
+		end := start + 1
+		for end < len(projects) && !overlapsAny(projects[end], projects[start:end]) {
+			end++
+		}
+
+		batch := projects[start:end]
+		errs := make([]error, len(batch))
+		var wg sync.WaitGroup
+		for i, p := range batch {
+			wg.Add(1)
+			go func(i int, p *config.Config) {
+				defer wg.Done()
+				if err := scaffold(ctx, p, filepath.Join(root, filepath.FromSlash(p.Output)), fs, coord); err != nil {
+					errs[i] = fmt.Errorf("project %q: %w", p.ProjectName, err)
+				}
+			}(i, p)
+		}
+		wg.Wait()
+
+		if err := errors.Join(errs...); err != nil {
+			return err
+		}
+		start = end
+	}
+	return nil
+}
+
+// reportInterrupted lists what an interrupted run wrote. Nothing is rolled
+// back: a file it overwrote already lost its old content, and the ones it
+// created may be wanted. Existing files are never emptied and are replaced
+// atomically, so none is left half written; a file the run created may still
+// be empty if it stopped before generating its content.
+func reportInterrupted(entries []builder.JournalEntry) {
+	if len(entries) == 0 {
+		fmt.Println("⚠️  Interrupted before anything was written.")
+		return
+	}
+	fmt.Printf("⚠️  Interrupted. These %d change(s) were made before stopping:\n", len(entries))
+	for _, e := range entries {
+		fmt.Printf("   %s %s\n", e.Kind, e.Path)
+	}
+}
+
+// overlapsAny reports whether p's output overlaps any of the others'.
+func overlapsAny(p *config.Config, others []*config.Config) bool {
+	for _, o := range others {
+		if config.OutputsOverlap(p.Output, o.Output) {
+			return true
+		}
+	}
+	return false
+}
+
 func init() {
 	initCmd.Flags().StringVarP(
 		&cfgFile, "config", "c", "config.yaml",
-		"path to the YAML project spec",
+		"path to the project spec (YAML, JSON or TOML; - for stdin)",
 	)
 	initCmd.Flags().StringVarP(
 		&outputDir, "output", "o", ".",
 		"directory where the project will be generated (default is current directory)",
 	)
+	initCmd.Flags().IntVarP(
+		&jobs, "jobs", "j", runtime.NumCPU(),
+		"number of files to render and write at once, across the whole run",
+	)
+
+	addFormatFlag(initCmd)
+	addPortabilityFlag(initCmd)
 
 	rootCmd.AddCommand(initCmd)
 }
@@ -42,7 +42,7 @@ var listTemplatesCmd = &cobra.Command{
 
 		// List templates for specific language
 		language := args[0]
-		listTemplatesForLanguage(language, generators)
+		listTemplatesForLanguage(language, generators, factory)
 	},
 }
 
@@ -70,7 +70,7 @@ func listAllLanguages(generators []generator.Generator) {
 }
 
 // listTemplatesForLanguage shows templates for a specific language
-func listTemplatesForLanguage(language string, generators []generator.Generator) {
+func listTemplatesForLanguage(language string, generators []generator.Generator, factory *generator.GeneratorFactory) {
 	// Find the generator for this language
 	var targetGen generator.Generator
 	for _, gen := range generators {
@@ -90,13 +90,19 @@ func listTemplatesForLanguage(language string, generators []generator.Generator)
 	}
 
 	// Get templates for this language
-	templates, err := getTemplatesForLanguage(language)
+	templates, err := factory.GetTemplatesForLanguage(language)
 	if err != nil {
 		fmt.Printf("❌ Error reading templates for '%s': %v\n", language, err)
 		return
 	}
 
-	if len(templates) == 0 {
+	assets, err := factory.GetAssetsForLanguage(language)
+	if err != nil {
+		fmt.Printf("❌ Error reading assets for '%s': %v\n", language, err)
+		return
+	}
+
+	if len(templates) == 0 && len(assets) == 0 {
 		fmt.Printf("No templates found for '%s'\n", language)
 		return
 	}
@@ -106,11 +112,14 @@ func listTemplatesForLanguage(language string, generators []generator.Generator)
 	for _, tmpl := range templates {
 		fmt.Printf("  %s\n", tmpl)
 	}
-}
 
-// getTemplatesForLanguage reads template files from embedded filesystem
-func getTemplatesForLanguage(language string) ([]string, error) {
-	return generator.GetTemplatesForLanguage(language)
+	if len(assets) > 0 {
+		fmt.Printf("\nAssets for '%s' (copied as is):\n", language)
+		sort.Strings(assets)
+		for _, asset := range assets {
+			fmt.Printf("  %s\n", asset)
+		}
+	}
 }
 
 func init() {
@@ -5,6 +5,15 @@
 package cmd
 
 import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/KoHorizon/ForgeDir/internal/config"
+	"github.com/KoHorizon/ForgeDir/internal/utils"
 	"github.com/spf13/cobra"
 )
 
@@ -12,8 +21,27 @@ var (
 	cfgFile      string
 	outputDir    string
 	templatesDir string // New: custom templates directory
+	specFormat   string // spec format, picked by extension when empty
+	portability  string // portability profile overriding the spec's
+	jobs         int    // files rendered and written at once, across the whole run
 )
 
+// addFormatFlag registers the --format flag choosing the spec format
+func addFormatFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(
+		&specFormat, "format", "",
+		"spec format: "+strings.Join(config.Formats, ", ")+" (default: from the file extension, yaml for stdin)",
+	)
+}
+
+// addPortabilityFlag registers the --portability flag choosing the path rules
+func addPortabilityFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(
+		&portability, "portability", "",
+		"path rules for node names: "+strings.Join(utils.PortabilityProfiles, ", ")+" (default: the spec's portability key, or portable)",
+	)
+}
+
 // rootCmd is now just the top‐level command (no Run or RunE)
 var rootCmd = &cobra.Command{
 	Use:   "fgdir",
@@ -33,7 +61,21 @@ func init() {
 	)
 }
 
-// Execute runs the CLI.
+// Execute runs the CLI, exiting with status 1 when the command fails. The
+// first SIGINT or SIGTERM cancels the command's context so it can stop
+// cleanly, exiting with status 130; a second one kills the process.
 func Execute() {
-	rootCmd.Execute()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	defer stop()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		if errors.Is(err, context.Canceled) {
+			os.Exit(130)
+		}
+		os.Exit(1)
+	}
 }
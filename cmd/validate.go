@@ -5,15 +5,27 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/KoHorizon/ForgeDir/internal/config"
+	"github.com/KoHorizon/ForgeDir/internal/generator"
 	"github.com/KoHorizon/ForgeDir/internal/utils"
+	"github.com/goccy/go-yaml"
 	"github.com/spf13/cobra"
 )
 
+var (
+	printResolved  bool
+	validateReport string // report format: text, json or sarif
+)
+
 var validateCmd = &cobra.Command{
-	Use:                   "validate [spec.yaml]",
+	Use:                   "validate [spec.yaml|-]",
 	Short:                 "Validate that a spec.yaml is well-formed",
 	Args:                  cobra.MaximumNArgs(1),
 	DisableFlagsInUseLine: true, // Hide "[flags]" in usage
@@ -25,83 +37,357 @@ var validateCmd = &cobra.Command{
 			configPath = args[0]
 		}
 
+		report, err := newReport(validateReport, configPath)
+		if err != nil {
+			return err
+		}
+		if printResolved && validateReport != reportText {
+			return fmt.Errorf("--resolved cannot be combined with --report %s", validateReport)
+		}
+
 		// Try to load the config
-		cfg, err := config.LoadConfigFromYaml(configPath)
+		cfg, err := config.LoadWith(configPath, config.LoadOptions{Format: specFormat, Portability: portability})
 		if err != nil {
-			fmt.Printf("❌ Validation failed: %v\n", err)
-			return fmt.Errorf("invalid configuration")
+			report.loadError(err)
+			return report.finish(nil)
 		}
 
-		// Perform additional validation
-		if err := validateConfig(cfg); err != nil {
-			fmt.Printf("❌ Validation failed: %v\n", err)
-			return fmt.Errorf("configuration validation errors")
+		// Show the spec after includes and extends were applied
+		if printResolved {
+			out, err := yaml.MarshalWithOptions(cfg, yaml.IndentSequence(true))
+			if err != nil {
+				return fmt.Errorf("printing resolved configuration: %w", err)
+			}
+			fmt.Printf("# Resolved configuration '%s'\n%s\n", configPath, out)
 		}
 
-		// Success!
-		fmt.Printf("✅ Configuration '%s' is valid\n", configPath)
-		fmt.Printf("   Project: %s\n", cfg.ProjectName)
-		fmt.Printf("   Language: %s\n", cfg.Language)
-		fmt.Printf("   Structure nodes: %d\n", countNodes(cfg.Structure))
+		// Languages and templates, to check language and template keys against
+		languages, err := availableTemplates()
+		if err != nil {
+			report.issues = append(report.issues, issue{Rule: ruleTemplateSet, Message: err.Error()})
+			return report.finish(nil)
+		}
 
-		return nil
+		// Perform additional validation
+		v := &validator{languages: languages}
+		v.config(cfg)
+		report.issues = v.issues
+		return report.finish(cfg)
 	},
 }
 
-// validateConfig performs business logic validation beyond YAML parsing
-func validateConfig(cfg *config.Config) error {
+// templateIndex maps every language of the active templates to its template and asset names
+type templateIndex map[string]map[string]bool
+
+// availableTemplates indexes the languages and templates of the active template source
+func availableTemplates() (templateIndex, error) {
+	templateSource, err := generator.CreateTemplateSource(templatesDir)
+	if err != nil {
+		return nil, fmt.Errorf("setting up templates: %w", err)
+	}
+	list, err := templateSource.ListLanguages()
+	if err != nil {
+		return nil, fmt.Errorf("reading languages: %w", err)
+	}
+
+	index := make(templateIndex, len(list))
+	for _, lang := range list {
+		names, err := templateSource.ListTemplates(lang)
+		if err != nil {
+			return nil, fmt.Errorf("reading templates for %s: %w", lang, err)
+		}
+		assets, err := templateSource.ListAssets(lang)
+		if err != nil {
+			return nil, fmt.Errorf("reading assets for %s: %w", lang, err)
+		}
+		index[lang] = make(map[string]bool, len(names)+len(assets))
+		for _, name := range append(names, assets...) {
+			index[lang][name] = true
+		}
+	}
+	return index, nil
+}
+
+// hasTemplate reports whether a node's `template` key resolves for the language
+func (t templateIndex) hasTemplate(language, ref, fileName string) bool {
+	for _, candidate := range generator.TemplateCandidates(ref, fileName) {
+		if t[language][candidate] {
+			return true
+		}
+	}
+	return false
+}
+
+// validator checks a spec beyond YAML parsing, collecting every problem
+// instead of stopping at the first one
+type validator struct {
+	languages   templateIndex
+	portability *utils.Portability // path rules of the project being checked
+	output      string             // output of the project being checked, counted in path lengths
+	prefix      string             // names the project being checked in monorepo specs
+	issues      []issue
+}
+
+// add records a problem; node is nil for problems of the spec as a whole
+func (v *validator) add(rule string, node *config.StructureNode, path string, err error) {
+	var pos config.Position
+	if node != nil {
+		pos = node.Pos
+	}
+	v.addAt(rule, pos, path, err)
+}
+
+// addAt records a problem declared at pos
+func (v *validator) addAt(rule string, pos config.Position, path string, err error) {
+	v.issues = append(v.issues, issue{Rule: rule, Path: path, Pos: pos, Message: v.prefix + err.Error()})
+}
+
+// expandErrors records every node that failed to expand
+func (v *validator) expandErrors(err error) {
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+	for _, err := range errs {
+		var nodeErr *config.NodeError
+		if errors.As(err, &nodeErr) {
+			v.addAt(ruleExpand, nodeErr.Pos, nodeErr.Path, nodeErr.Err)
+		} else {
+			v.add(ruleExpand, nil, "", err)
+		}
+	}
+}
+
+// outputPath returns a node path relative to the output directory
+func (v *validator) outputPath(nodePath string) string {
+	if v.output == "" || v.output == "." {
+		return nodePath
+	}
+	return v.output + nodePath
+}
+
+// config validates a spec, or every project of a monorepo spec
+func (v *validator) config(cfg *config.Config) {
 	// Check required fields
 	if cfg.ProjectName == "" {
-		return fmt.Errorf("projectName is required")
+		v.add(ruleRequired, nil, "", fmt.Errorf("projectName is required"))
 	}
 
+	if len(cfg.Projects) > 0 {
+		v.monorepo(cfg)
+		return
+	}
+
+	profile, err := utils.PortabilityProfile(cfg.Portability)
+	if err != nil {
+		v.add(rulePortability, nil, "", err)
+		return
+	}
+	v.portability = profile
+	v.output = cfg.Output
+
+	// Validate the tree the builder will actually create, without the nodes
+	// that failed to expand
+	expanded, err := cfg.Expand()
+	if err != nil {
+		v.expandErrors(err)
+		if expanded == nil {
+			return
+		}
+	}
+	cfg = expanded
+
 	if cfg.Language == "" {
-		return fmt.Errorf("language is required")
+		v.add(ruleRequired, nil, "", fmt.Errorf("language is required"))
+	} else if _, ok := v.languages[cfg.Language]; !ok {
+		v.add(ruleLanguage, nil, "", fmt.Errorf("unknown language '%s' (available: %s)", cfg.Language, languageList(v.languages)))
 	}
 
 	if len(cfg.Structure) == 0 {
-		return fmt.Errorf("structure cannot be empty")
+		v.add(ruleRequired, nil, "", fmt.Errorf("structure cannot be empty"))
 	}
 
 	// Validate structure nodes (including path security)
-	return validateStructureNodes(cfg.Structure, "")
+	v.nodes(cfg.Structure, "", cfg.Language)
 }
 
-// validateStructureNodes validates each node in the structure tree
-func validateStructureNodes(nodes []config.StructureNode, path string) error {
-	for _, node := range nodes {
+// monorepo validates the shared root files and every project of a monorepo spec
+func (v *validator) monorepo(cfg *config.Config) {
+	projects, err := cfg.Subprojects()
+	if err != nil {
+		v.add(ruleProject, nil, "", err)
+		return
+	}
+
+	prefix := v.prefix
+	defer func() { v.prefix = prefix }()
+
+	if len(cfg.Structure) > 0 {
+		root := *cfg
+		root.Projects = nil
+		v.prefix = prefix + "root structure: "
+		v.config(&root)
+	}
+
+	outputs := map[string]string{}
+	for _, p := range projects {
+		v.prefix = fmt.Sprintf("%sproject '%s': ", prefix, p.ProjectName)
+		v.config(p)
+		v.prefix = prefix
+		if other, ok := outputs[p.Output]; ok {
+			v.add(ruleProject, nil, "", fmt.Errorf("projects '%s' and '%s' share the output directory '%s'", other, p.ProjectName, p.Output))
+		}
+		outputs[p.Output] = p.ProjectName
+	}
+}
+
+// nodes validates each node in the structure tree;
+// language is the effective language inherited from the parent
+func (v *validator) nodes(nodes []config.StructureNode, path string, language string) {
+	// Siblings must not overwrite each other, on any file system
+	for _, c := range config.Collisions(nodes) {
+		v.add(ruleCollision, &c.Second, path+"/"+c.Second.Name, fmt.Errorf("name collision in %s/: %w", path, c))
+	}
+
+	for i := range nodes {
+		node := &nodes[i]
 		currentPath := path + "/" + node.Name
 
 		// Validate node type
-		if node.Type != config.TypeDir && node.Type != config.TypeFile {
-			return fmt.Errorf("invalid type '%s' at %s (must be 'dir' or 'file')", node.Type, currentPath)
+		switch node.Type {
+		case config.TypeDir, config.TypeFile, config.TypeSymlink, config.TypeCopy:
+		default:
+			v.add(ruleType, node, currentPath, fmt.Errorf("invalid type '%s' at %s (must be 'dir', 'file', 'symlink' or 'copy')", node.Type, currentPath))
 		}
 
 		// Validate node name
 		if node.Name == "" {
-			return fmt.Errorf("name is required at %s", currentPath)
+			v.add(ruleName, node, currentPath, fmt.Errorf("name is required at %s", currentPath))
+		} else if err := v.portability.ValidateName(node.Name); err != nil {
+			// Security validation: Check for path traversal and other security issues
+			v.add(ruleName, node, currentPath, fmt.Errorf("security validation failed for '%s' at %s: %w", node.Name, currentPath, err))
+		} else if err := v.portability.ValidateLength(v.outputPath(currentPath)); err != nil {
+			v.add(ruleName, node, currentPath, fmt.Errorf("%w at %s", err, currentPath))
 		}
 
-		// Security validation: Check for path traversal and other security issues
-		if err := utils.ValidatePath(node.Name); err != nil {
-			return fmt.Errorf("security validation failed for '%s' at %s: %w", node.Name, currentPath, err)
+		// Language overrides must have templates
+		nodeLanguage := language
+		if node.Language != "" {
+			if _, ok := v.languages[node.Language]; !ok {
+				v.add(ruleLanguage, node, currentPath, fmt.Errorf("unknown language '%s' at %s (available: %s)", node.Language, currentPath, languageList(v.languages)))
+			} else {
+				nodeLanguage = node.Language
+			}
 		}
 
-		// Files cannot have children
-		if node.Type == config.TypeFile && len(node.Children) > 0 {
-			return fmt.Errorf("file '%s' cannot have children", currentPath)
+		// Only directories have children
+		if node.Type != config.TypeDir && len(node.Children) > 0 {
+			v.add(ruleChildren, node, currentPath, fmt.Errorf("%s '%s' cannot have children", node.Type, currentPath))
 		}
 
-		// Recursively validate children
-		if len(node.Children) > 0 {
-			if err := validateStructureNodes(node.Children, currentPath); err != nil {
-				return err
+		// Symlinks need a target inside the project, and nothing else has one
+		if err := validateSymlink(*node, currentPath); err != nil {
+			v.add(ruleSymlink, node, currentPath, err)
+		}
+
+		// Modes must be octal permission bits
+		if _, err := node.Mode.Perm(0); err != nil {
+			v.add(ruleMode, node, currentPath, fmt.Errorf("%w at %s", err, currentPath))
+		}
+
+		// Copies need something to copy, and nothing else has a source
+		if err := validateCopy(*node, currentPath); err != nil {
+			v.add(ruleCopy, node, currentPath, err)
+		}
+
+		// Inline content belongs to files, and comes from one place only
+		if err := validateContent(*node, currentPath); err != nil {
+			v.add(ruleContent, node, currentPath, err)
+		}
+
+		// A template chosen by name must exist for the node's language
+		if node.Template != "" {
+			if node.Type != config.TypeFile {
+				v.add(ruleTemplate, node, currentPath, fmt.Errorf("template is only allowed on files, not on %s '%s'", node.Type, currentPath))
+			} else if !v.languages.hasTemplate(nodeLanguage, node.Template, node.Name) {
+				v.add(ruleTemplate, node, currentPath, fmt.Errorf("template '%s' at %s not found for language '%s'", node.Template, currentPath, nodeLanguage))
 			}
 		}
+
+		// Recursively validate children
+		v.nodes(node.Children, currentPath, nodeLanguage)
+	}
+}
+
+// validateSymlink checks the target of a symlink node lexically, as if the
+// project were generated in an empty directory
+func validateSymlink(node config.StructureNode, currentPath string) error {
+	if node.Type != config.TypeSymlink {
+		if node.Target != "" {
+			return fmt.Errorf("target is only allowed on symlinks, not on %s '%s'", node.Type, currentPath)
+		}
+		return nil
+	}
+	if node.Mode != "" {
+		return fmt.Errorf("symlink '%s' cannot have a mode", currentPath)
+	}
+	link := filepath.Join(".", filepath.FromSlash(currentPath))
+	if _, err := utils.LexicalLinkTarget(".", link, node.Target); err != nil {
+		return fmt.Errorf("invalid symlink '%s': %w", currentPath, err)
+	}
+	return nil
+}
+
+// validateCopy checks the from path of a copy node
+func validateCopy(node config.StructureNode, currentPath string) error {
+	if node.Type != config.TypeCopy {
+		if node.From != "" {
+			return fmt.Errorf("from is only allowed on copies, not on %s '%s'", node.Type, currentPath)
+		}
+		return nil
+	}
+	if node.From == "" {
+		return fmt.Errorf("copy '%s' needs a 'from' path", currentPath)
+	}
+	info, err := os.Lstat(node.From)
+	if err != nil {
+		return fmt.Errorf("from of '%s': %w", currentPath, err)
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		return fmt.Errorf("from of '%s' is a symlink; only files and directories can be copied", currentPath)
+	}
+	return nil
+}
+
+// validateContent checks the content and contentFrom keys of a node
+func validateContent(node config.StructureNode, currentPath string) error {
+	if node.Content == nil && node.ContentFrom == "" {
+		return nil
+	}
+	if node.Type != config.TypeFile {
+		return fmt.Errorf("content is only allowed on files, not on %s '%s'", node.Type, currentPath)
+	}
+	if node.Content != nil && node.ContentFrom != "" {
+		return fmt.Errorf("file '%s' cannot have both content and contentFrom", currentPath)
+	}
+	if node.ContentFrom != "" {
+		if _, err := os.Stat(node.ContentFrom); err != nil {
+			return fmt.Errorf("contentFrom of '%s': %w", currentPath, err)
+		}
 	}
 	return nil
 }
 
+// languageList formats the available languages for error messages
+func languageList(languages templateIndex) string {
+	list := make([]string, 0, len(languages))
+	for lang := range languages {
+		list = append(list, lang)
+	}
+	sort.Strings(list)
+	return strings.Join(list, ", ")
+}
+
 // countNodes counts total nodes in the structure tree
 func countNodes(nodes []config.StructureNode) int {
 	count := len(nodes)
@@ -112,5 +398,17 @@ func countNodes(nodes []config.StructureNode) int {
 }
 
 func init() {
+	validateCmd.Flags().BoolVar(
+		&printResolved, "resolved", false,
+		"print the final spec after includes and extends are merged",
+	)
+
+	validateCmd.Flags().StringVar(
+		&validateReport, "report", reportText,
+		"report format: "+strings.Join(reportFormats, ", "),
+	)
+	addFormatFlag(validateCmd)
+	addPortabilityFlag(validateCmd)
+
 	rootCmd.AddCommand(validateCmd)
 }
@@ -0,0 +1,310 @@
+// Copyright © 2025 KoHorizon
+// Licensed under the MIT License.
+// See LICENSE file in the project root for full license information.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/KoHorizon/ForgeDir/internal/config"
+)
+
+// Report formats of the validate command
+const (
+	reportText  = "text"
+	reportJSON  = "json"
+	reportSARIF = "sarif"
+)
+
+var reportFormats = []string{reportText, reportJSON, reportSARIF}
+
+// Rules group validation problems; they are the rule ids of SARIF reports
+const (
+	ruleSpec        = "spec"
+	ruleTemplateSet = "template-set"
+	ruleRequired    = "required"
+	rulePortability = "portability"
+	ruleExpand      = "expand"
+	ruleProject     = "project"
+	ruleType        = "type"
+	ruleName        = "name"
+	ruleCollision   = "collision"
+	ruleLanguage    = "language"
+	ruleChildren    = "children"
+	ruleSymlink     = "symlink"
+	ruleMode        = "mode"
+	ruleCopy        = "copy"
+	ruleContent     = "content"
+	ruleTemplate    = "template"
+)
+
+// rules lists every rule with its description, in report order
+var rules = []struct{ id, description string }{
+	{ruleSpec, "The spec file can be read and decoded, with known keys only"},
+	{ruleTemplateSet, "The templates to check the spec against can be read"},
+	{ruleRequired, "projectName, language and structure are set"},
+	{rulePortability, "The portability profile exists"},
+	{ruleExpand, "when, forEach and templated names evaluate"},
+	{ruleProject, "Monorepo projects are well-formed and use distinct outputs"},
+	{ruleType, "Node types are dir, file, symlink or copy"},
+	{ruleName, "Node names are set, safe and portable path components"},
+	{ruleCollision, "Sibling names differ, also ignoring case and Unicode normalization"},
+	{ruleLanguage, "Languages have templates"},
+	{ruleChildren, "Only directories have children"},
+	{ruleSymlink, "Symlinks have a target inside the project"},
+	{ruleMode, "Modes are octal permission bits"},
+	{ruleCopy, "Copies have a local source to copy"},
+	{ruleContent, "Inline content is set on files only, from one place"},
+	{ruleTemplate, "Templates chosen by name exist"},
+}
+
+// issue is one problem found in a spec
+type issue struct {
+	Rule    string
+	Path    string // node path ("/src/main.go"), empty for problems of the whole spec
+	Message string
+	Pos     config.Position
+	Snippet string // source line with a caret, for decoding errors
+}
+
+// report prints the problems found in a spec in the chosen format
+type report struct {
+	format string
+	spec   string
+	issues []issue
+}
+
+func newReport(format, spec string) (*report, error) {
+	for _, f := range reportFormats {
+		if f == format {
+			return &report{format: format, spec: spec}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown report format %q (available: %s)", format, strings.Join(reportFormats, ", "))
+}
+
+// loadError records the error of loading the spec, one issue per decoding
+// problem when it has positions
+func (r *report) loadError(err error) {
+	var list config.SpecErrors
+	var single *config.SpecError
+	switch {
+	case errors.As(err, &list):
+	case errors.As(err, &single):
+		list = config.SpecErrors{single}
+	default:
+		r.issues = append(r.issues, issue{Rule: ruleSpec, Message: err.Error()})
+		return
+	}
+	for _, e := range list {
+		r.issues = append(r.issues, issue{
+			Rule:    ruleSpec,
+			Message: e.Message,
+			Pos:     config.Position{File: e.File, Line: e.Line, Column: e.Column},
+			Snippet: e.Snippet,
+		})
+	}
+}
+
+// finish prints the report; it fails when there is any problem. cfg is the
+// loaded spec, nil when it could not be loaded.
+func (r *report) finish(cfg *config.Config) error {
+	var err error
+	switch r.format {
+	case reportJSON:
+		err = r.printJSON()
+	case reportSARIF:
+		err = r.printSARIF()
+	default:
+		r.printText(cfg)
+	}
+	if err != nil {
+		return err
+	}
+	if len(r.issues) > 0 {
+		return fmt.Errorf("invalid configuration")
+	}
+	return nil
+}
+
+// file is the spec file an issue is in, relative to the working directory
+func (r *report) file(is issue) string {
+	file := is.Pos.File
+	if file == "" {
+		file = r.spec
+	}
+	if filepath.IsAbs(file) {
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, file); err == nil && !strings.HasPrefix(rel, "..") {
+				file = rel
+			}
+		}
+	}
+	return filepath.ToSlash(file)
+}
+
+func (r *report) printText(cfg *config.Config) {
+	if len(r.issues) > 0 {
+		problems := "problems"
+		if len(r.issues) == 1 {
+			problems = "problem"
+		}
+		fmt.Printf("❌ Validation failed: %d %s in '%s'\n", len(r.issues), problems, r.spec)
+		for _, is := range r.issues {
+			loc := r.file(is)
+			if is.Pos.Line > 0 {
+				loc = fmt.Sprintf("%s:%d:%d", loc, is.Pos.Line, is.Pos.Column)
+			}
+			fmt.Printf("   %s: %s\n", loc, is.Message)
+			if is.Snippet != "" {
+				fmt.Printf("   %s\n", strings.ReplaceAll(is.Snippet, "\n", "\n   "))
+			}
+		}
+		return
+	}
+
+	// Success!
+	fmt.Printf("✅ Configuration '%s' is valid\n", r.spec)
+	fmt.Printf("   Project: %s\n", cfg.ProjectName)
+	fmt.Printf("   Language: %s\n", cfg.Language)
+	if langs := cfg.Languages(); len(langs) > 1 {
+		fmt.Printf("   Other languages: %s\n", strings.Join(langs[1:], ", "))
+	}
+	expanded, _ := cfg.Expand() // already checked by the validator
+	fmt.Printf("   Structure nodes: %d\n", countNodes(expanded.Structure))
+	projects, _ := cfg.Subprojects() // already checked by the validator
+	for _, p := range projects {
+		expanded, _ := p.Expand()
+		fmt.Printf("   Project %s (%s) in %s: %d nodes\n", p.ProjectName, p.Language, p.Output, countNodes(expanded.Structure))
+	}
+}
+
+// jsonProblem is an issue in the JSON report
+type jsonProblem struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	Path    string `json:"path,omitempty"`
+	File    string `json:"file"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+}
+
+func (r *report) printJSON() error {
+	out := struct {
+		Spec     string        `json:"spec"`
+		Valid    bool          `json:"valid"`
+		Problems []jsonProblem `json:"problems"`
+	}{Spec: r.spec, Valid: len(r.issues) == 0, Problems: []jsonProblem{}}
+
+	for _, is := range r.issues {
+		out.Problems = append(out.Problems, jsonProblem{
+			Rule:    is.Rule,
+			Message: is.Message,
+			Path:    is.Path,
+			File:    r.file(is),
+			Line:    is.Pos.Line,
+			Column:  is.Pos.Column,
+		})
+	}
+	return printIndented(out)
+}
+
+// SARIF 2.1.0 report, with only the properties code scanning tools need
+type (
+	sarifLog struct {
+		Schema  string     `json:"$schema"`
+		Version string     `json:"version"`
+		Runs    []sarifRun `json:"runs"`
+	}
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		Name           string      `json:"name"`
+		Version        string      `json:"version"`
+		InformationURI string      `json:"informationUri"`
+		Rules          []sarifRule `json:"rules"`
+	}
+	sarifRule struct {
+		ID               string       `json:"id"`
+		ShortDescription sarifMessage `json:"shortDescription"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifResult struct {
+		RuleID    string          `json:"ruleId"`
+		RuleIndex int             `json:"ruleIndex"`
+		Level     string          `json:"level"`
+		Message   sarifMessage    `json:"message"`
+		Locations []sarifLocation `json:"locations"`
+	}
+	sarifLocation struct {
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	}
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifact `json:"artifactLocation"`
+		Region           *sarifRegion  `json:"region,omitempty"`
+	}
+	sarifArtifact struct {
+		URI string `json:"uri"`
+	}
+	sarifRegion struct {
+		StartLine   int `json:"startLine"`
+		StartColumn int `json:"startColumn,omitempty"`
+	}
+)
+
+func (r *report) printSARIF() error {
+	driver := sarifDriver{
+		Name:           "fgdir",
+		Version:        getVersion(),
+		InformationURI: "https://github.com/KoHorizon/ForgeDir",
+	}
+	index := map[string]int{}
+	for i, rule := range rules {
+		driver.Rules = append(driver.Rules, sarifRule{ID: rule.id, ShortDescription: sarifMessage{rule.description}})
+		index[rule.id] = i
+	}
+
+	results := []sarifResult{}
+	for _, is := range r.issues {
+		loc := sarifPhysicalLocation{ArtifactLocation: sarifArtifact{URI: r.file(is)}}
+		if is.Pos.Line > 0 {
+			loc.Region = &sarifRegion{StartLine: is.Pos.Line, StartColumn: is.Pos.Column}
+		}
+		results = append(results, sarifResult{
+			RuleID:    is.Rule,
+			RuleIndex: index[is.Rule],
+			Level:     "error",
+			Message:   sarifMessage{is.Message},
+			Locations: []sarifLocation{{PhysicalLocation: loc}},
+		})
+	}
+
+	return printIndented(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	})
+}
+
+// printIndented writes v to standard output as indented JSON
+func printIndented(v any) error {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
@@ -0,0 +1,326 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// problemSpec declares three problems, on lines 4, 6 and 11
+const problemSpec = `projectName: demo
+language: go
+structure:
+  - type: folder
+    name: src
+  - type: file
+    name: "notes?.txt"
+  - type: dir
+    name: scripts
+    children:
+      - type: file
+        name: run.sh
+        mode: "999"
+`
+
+// runValidate runs `fgdir validate` on a spec.yaml holding spec, from the
+// directory of the spec, and returns what it printed on standard output
+func runValidate(t *testing.T, spec string, args ...string) (string, error) {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("spec.yaml", []byte(spec), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// flags keep their values between runs
+	printResolved, validateReport, specFormat, portability, templatesDir = false, reportText, "", "", ""
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	rootCmd.SetArgs(append([]string{"validate", "spec.yaml"}, args...))
+	rootCmd.SetErr(io.Discard)
+	runErr := rootCmd.Execute()
+
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out), runErr
+}
+
+func TestValidate_ReportsEveryProblem(t *testing.T) {
+	out, err := runValidate(t, problemSpec)
+	if err == nil {
+		t.Fatal("expected validation to fail")
+	}
+
+	for _, want := range []string{
+		"3 problems in 'spec.yaml'",
+		"spec.yaml:4:5: invalid type 'folder' at /src",
+		"spec.yaml:6:5: security validation failed for 'notes?.txt' at /notes?.txt",
+		`spec.yaml:11:9: invalid mode "999"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestValidate_JSONReport(t *testing.T) {
+	out, err := runValidate(t, problemSpec, "--report", "json")
+	if err == nil {
+		t.Fatal("expected validation to fail")
+	}
+
+	var report struct {
+		Spec     string `json:"spec"`
+		Valid    bool   `json:"valid"`
+		Problems []struct {
+			Rule    string `json:"rule"`
+			Message string `json:"message"`
+			Path    string `json:"path"`
+			File    string `json:"file"`
+			Line    int    `json:"line"`
+			Column  int    `json:"column"`
+		} `json:"problems"`
+	}
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("invalid JSON report: %v\n%s", err, out)
+	}
+	if report.Spec != "spec.yaml" || report.Valid {
+		t.Errorf("expected an invalid spec.yaml, got spec %q valid %v", report.Spec, report.Valid)
+	}
+
+	want := []struct {
+		rule, path   string
+		line, column int
+	}{
+		{ruleType, "/src", 4, 5},
+		{ruleName, "/notes?.txt", 6, 5},
+		{ruleMode, "/scripts/run.sh", 11, 9},
+	}
+	if len(report.Problems) != len(want) {
+		t.Fatalf("expected %d problems, got %+v", len(want), report.Problems)
+	}
+	for i, w := range want {
+		p := report.Problems[i]
+		if p.Rule != w.rule || p.Path != w.path || p.File != "spec.yaml" || p.Line != w.line || p.Column != w.column || p.Message == "" {
+			t.Errorf("problem %d: expected %s at %s (spec.yaml:%d:%d), got %+v", i, w.rule, w.path, w.line, w.column, p)
+		}
+	}
+
+	out, err = runValidate(t, "projectName: demo\nlanguage: go\nstructure:\n  - type: file\n    name: main.go\n", "--report", "json")
+	if err != nil {
+		t.Fatalf("expected a valid spec, got %v", err)
+	}
+	if !strings.Contains(out, `"valid": true`) || !strings.Contains(out, `"problems": []`) {
+		t.Errorf("expected a valid report with no problems, got:\n%s", out)
+	}
+}
+
+func TestValidate_SARIFReport(t *testing.T) {
+	out, err := runValidate(t, problemSpec, "--report", "sarif")
+	if err == nil {
+		t.Fatal("expected validation to fail")
+	}
+
+	var log struct {
+		Schema  string `json:"$schema"`
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name  string `json:"name"`
+					Rules []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				RuleIndex int    `json:"ruleIndex"`
+				Level     string `json:"level"`
+				Message   struct {
+					Text string `json:"text"`
+				} `json:"message"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region struct {
+							StartLine   int `json:"startLine"`
+							StartColumn int `json:"startColumn"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal([]byte(out), &log); err != nil {
+		t.Fatalf("invalid SARIF log: %v\n%s", err, out)
+	}
+	if log.Version != "2.1.0" || !strings.Contains(log.Schema, "sarif-2.1.0") || len(log.Runs) != 1 {
+		t.Fatalf("expected one SARIF 2.1.0 run, got version %q schema %q with %d runs", log.Version, log.Schema, len(log.Runs))
+	}
+
+	run := log.Runs[0]
+	if run.Tool.Driver.Name != "fgdir" || len(run.Tool.Driver.Rules) != len(rules) {
+		t.Errorf("expected the fgdir driver with %d rules, got %+v", len(rules), run.Tool.Driver)
+	}
+	if len(run.Results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(run.Results))
+	}
+	for _, res := range run.Results {
+		if res.RuleIndex >= len(run.Tool.Driver.Rules) || run.Tool.Driver.Rules[res.RuleIndex].ID != res.RuleID {
+			t.Errorf("result %s points at the wrong rule index %d", res.RuleID, res.RuleIndex)
+		}
+		if res.Level != "error" || res.Message.Text == "" || len(res.Locations) != 1 {
+			t.Errorf("unexpected result %+v", res)
+		}
+	}
+	loc := run.Results[2].Locations[0].PhysicalLocation
+	if loc.ArtifactLocation.URI != "spec.yaml" || loc.Region.StartLine != 11 || loc.Region.StartColumn != 9 {
+		t.Errorf("expected the mode problem at spec.yaml:11:9, got %+v", loc)
+	}
+}
+
+func TestValidate_FormatIsTheSpecFormat(t *testing.T) {
+	spec := "projectName = \"demo\"\nlanguage = \"go\"\n\n[[structure]]\ntype = \"file\"\nname = \"main.go\"\n"
+	if _, err := runValidate(t, spec, "--format", "toml", "--report", "json"); err != nil {
+		t.Errorf("expected --format toml to read a TOML spec, got %v", err)
+	}
+	if _, err := runValidate(t, problemSpec, "--report", "xml"); err == nil || !strings.Contains(err.Error(), "unknown report format") {
+		t.Errorf("expected an unknown report format error, got %v", err)
+	}
+}
+
+// expandSpec declares three nodes that fail to expand, on lines 6, 9 and 12,
+// and a node of an invalid type on line 14
+const expandSpec = `projectName: demo
+language: go
+variables:
+  count: 3
+structure:
+  - type: file
+    name: a.go
+    when: "vars.missing =="
+  - type: file
+    name: "{{ .Item }}.go"
+    forEach: vars.count
+  - type: dir
+    name: "{{ .Oops"
+  - type: folder
+    name: src
+`
+
+func TestValidate_ReportsEveryExpandError(t *testing.T) {
+	out, err := runValidate(t, expandSpec, "--report", "json")
+	if err == nil {
+		t.Fatal("expected validation to fail")
+	}
+
+	var report struct {
+		Problems []struct {
+			Rule   string `json:"rule"`
+			Path   string `json:"path"`
+			Line   int    `json:"line"`
+			Column int    `json:"column"`
+		} `json:"problems"`
+	}
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("invalid JSON report: %v\n%s", err, out)
+	}
+
+	want := []struct {
+		rule, path string
+		line       int
+	}{
+		{ruleExpand, "/a.go", 6},
+		{ruleExpand, "/{{ .Item }}.go", 9},
+		{ruleExpand, "/{{ .Oops", 12},
+		{ruleType, "/src", 14},
+	}
+	if len(report.Problems) != len(want) {
+		t.Fatalf("expected %d problems, got %+v", len(want), report.Problems)
+	}
+	for i, w := range want {
+		p := report.Problems[i]
+		if p.Rule != w.rule || p.Path != w.path || p.Line != w.line || p.Column != 5 {
+			t.Errorf("problem %d: expected %s at %s (line %d), got %+v", i, w.rule, w.path, w.line, p)
+		}
+	}
+}
+
+func TestValidate_TemplatesErrorIsReported(t *testing.T) {
+	missing := t.TempDir() + "/missing"
+	out, err := runValidate(t, problemSpec, "--templates", missing, "--report", "json")
+	if err == nil {
+		t.Fatal("expected validation to fail")
+	}
+
+	var report struct {
+		Valid    bool `json:"valid"`
+		Problems []struct {
+			Rule string `json:"rule"`
+		} `json:"problems"`
+	}
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("invalid JSON report: %v\n%s", err, out)
+	}
+	if report.Valid || len(report.Problems) != 1 || report.Problems[0].Rule != ruleTemplateSet {
+		t.Errorf("expected one %s problem, got %+v", ruleTemplateSet, report)
+	}
+}
+
+// runImport runs `fgdir import` on a listing.txt holding listing, in the
+// current directory
+func runImport(t *testing.T, listing string, args ...string) error {
+	t.Helper()
+	if err := os.WriteFile("listing.txt", []byte(listing), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// flags keep their values between runs
+	importFrom, importLanguage, importName, importOutput, templatesDir = "tree", "", "", "", ""
+	importCmd.Flags().Lookup("language").Changed = false
+
+	rootCmd.SetArgs(append([]string{"import", "listing.txt"}, args...))
+	rootCmd.SetOut(io.Discard)
+	rootCmd.SetErr(io.Discard)
+	return rootCmd.Execute()
+}
+
+func TestValidate_ImportedSpec(t *testing.T) {
+	t.Chdir(t.TempDir())
+	listing := "my-api\n├── go.mod\n└── cmd\n    └── main.go\n"
+
+	if err := runImport(t, listing, "-o", "spec.yaml"); err == nil || !strings.Contains(err.Error(), "language") {
+		t.Errorf("expected import without --language to fail, got %v", err)
+	}
+	if err := runImport(t, listing, "-l", "cobol", "-o", "spec.yaml"); err == nil || !strings.Contains(err.Error(), "unknown language 'cobol'") {
+		t.Errorf("expected an unknown language error, got %v", err)
+	}
+	if _, err := os.Stat("spec.yaml"); err == nil {
+		t.Fatal("expected no spec to be written for a failed import")
+	}
+
+	if err := runImport(t, listing, "-l", "go", "-o", "spec.yaml"); err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	spec, err := os.ReadFile("spec.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out, err := runValidate(t, string(spec)); err != nil {
+		t.Errorf("expected the imported spec to be valid, got %v:\n%s", err, out)
+	}
+}
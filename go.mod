@@ -3,8 +3,10 @@ module github.com/KoHorizon/ForgeDir
 go 1.24.1
 
 require (
+	github.com/BurntSushi/toml v1.5.0
 	github.com/goccy/go-yaml v1.17.1
 	github.com/spf13/cobra v1.9.1
+	golang.org/x/text v0.28.0
 )
 
 require (
@@ -0,0 +1,88 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/KoHorizon/ForgeDir/internal/config"
+	"github.com/KoHorizon/ForgeDir/internal/utils"
+)
+
+// checkCopySource ensures the from path of a copy node can be copied.
+func checkCopySource(from string) error {
+	if from == "" {
+		return fmt.Errorf("copy needs a 'from' path")
+	}
+	info, err := os.Lstat(from)
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		return fmt.Errorf("%s is a symlink; copy only copies regular files and directories", from)
+	}
+	return nil
+}
+
+// copyNode copies the file or directory tree at from to target byte for byte.
+// Copied files keep their permissions unless mode is set. Symlinks inside a
+// copied directory are refused, so a copy cannot pull in files from elsewhere,
+// and every path it writes is checked against the project root, so a symlink
+// already in the output cannot send it elsewhere either.
+func (b *StructureBuilder) copyNode(ctx context.Context, from, target string, mode config.Mode) error {
+	info, err := os.Lstat(from)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return b.copyFile(ctx, from, target, info, mode)
+	}
+
+	return filepath.WalkDir(from, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(from, p)
+		if err != nil {
+			return err
+		}
+		dest, err := utils.SanitizeWritePath(b.projectRoot, filepath.Join(target, rel))
+		if err != nil {
+			return fmt.Errorf("path safety check failed for %q: %w", filepath.Join(target, rel), err)
+		}
+
+		switch {
+		case d.IsDir():
+			return b.fs.CreateFolder(ctx, dest, DefaultFolderPermission)
+		case d.Type().IsRegular():
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			return b.copyFile(ctx, p, dest, info, mode)
+		default:
+			return fmt.Errorf("cannot copy %s: not a regular file or directory", p)
+		}
+	})
+}
+
+// copyFile copies a single regular file.
+func (b *StructureBuilder) copyFile(ctx context.Context, from, target string, info os.FileInfo, mode config.Mode) error {
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("cannot copy %s: not a regular file or directory", from)
+	}
+	perm, err := mode.Perm(info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(from)
+	if err != nil {
+		return err
+	}
+	return b.fs.WriteFile(ctx, target, content, WithPerm(perm))
+}
@@ -1,14 +1,58 @@
 package builder
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/KoHorizon/ForgeDir/internal/config"
+	"github.com/KoHorizon/ForgeDir/internal/utils"
 )
 
+// FileSystem is where the builder and the generators create the project.
+// Every method fails with the context's error once it is cancelled, without
+// touching the disk. CreateFile creates an empty file only where there is
+// none, so the content of an existing file is only ever replaced by WriteFile.
 type FileSystem interface {
-	CreateFolder(path string, permission os.FileMode) error
-	WriteFile(path string, content []byte, permission os.FileMode) error
+	CreateFolder(ctx context.Context, path string, permission os.FileMode) error
+	CreateFile(ctx context.Context, path string, permission FilePerm) error
+	WriteFile(ctx context.Context, path string, content []byte, permission FilePerm) error
+	CreateSymlink(ctx context.Context, target, path string) error
+	SetPermission(ctx context.Context, path string, permission os.FileMode) error
+}
+
+// FilePerm is the permission CreateFile and WriteFile give a file. The zero
+// value, KeepPerm, asks for none: an existing file keeps its mode and a new
+// one gets DefaultFilePermission.
+type FilePerm struct {
+	perm os.FileMode
+	set  bool
+}
+
+// KeepPerm asks for no particular permission.
+var KeepPerm FilePerm
+
+// WithPerm asks for exactly perm, whatever the file had before.
+func WithPerm(perm os.FileMode) FilePerm {
+	return FilePerm{perm: perm, set: true}
+}
+
+// Get returns the permission asked for, and whether there is one.
+func (p FilePerm) Get() (os.FileMode, bool) {
+	return p.perm, p.set
+}
+
+// String returns the permission in octal, or "keep" for KeepPerm.
+func (p FilePerm) String() string {
+	if !p.set {
+		return "keep"
+	}
+	return fmt.Sprintf("%#o", p.perm)
 }
 
 type OSFileSystem struct{}
@@ -41,36 +85,305 @@ func NewOSFileSystem() *OSFileSystem {
 // Format: 0<Owner><Group><Others> (each digit is a sum of r=4, w=2, x=1)
 // Note: Permissions are based on standard UNIX file mode bits, stable across Go versions.
 //
-// CreateFolder creates a folder
-func (o *OSFileSystem) CreateFolder(folderPath string, permission os.FileMode) error {
-	err := os.MkdirAll(folderPath, permission)
-	if err != nil {
+// CreateFolder creates a folder. A folder it creates gets exactly the given
+// permission, whatever the umask; an existing folder is left untouched, and
+// missing parents get DefaultFolderPermission.
+func (o *OSFileSystem) CreateFolder(ctx context.Context, folderPath string, permission os.FileMode) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	_, statErr := os.Stat(folderPath)
+	if err := os.MkdirAll(filepath.Dir(folderPath), DefaultFolderPermission); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(folderPath, permission); err != nil {
 		return err
 	}
-	fmt.Printf("Created folder: %s\n", folderPath)
+	if os.IsNotExist(statErr) {
+		if err := os.Chmod(folderPath, permission); err != nil {
+			return fmt.Errorf("chmod %s: %w", folderPath, err)
+		}
+	}
+	logf(ctx, "Created folder: %s\n", folderPath)
 	return nil
 }
 
-// WriteFile ensures the parent directory exists, then creates or truncates
-// the file at path and writes the provided content with the specified permission.
-func (o *OSFileSystem) WriteFile(path string, content []byte, permission os.FileMode) error {
+// CreateFile ensures the parent directory exists, then creates an empty file
+// at path unless something is already there. An existing file keeps its
+// content, and gets the permission only if one is asked for.
+func (o *OSFileSystem) CreateFile(ctx context.Context, path string, permission FilePerm) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, DefaultFolderPermission); err != nil {
+		return fmt.Errorf("mkdir parent %s: %w", dir, err)
+	}
+
+	perm, set := permission.Get()
+	if !set {
+		perm = DefaultFilePermission
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if errors.Is(err, fs.ErrExist) {
+		if set {
+			if err := os.Chmod(path, perm); err != nil {
+				return fmt.Errorf("chmod %s: %w", path, err)
+			}
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("create file %s: %w", path, err)
+	}
+	// OpenFile applies the umask; chmod does not
+	err = f.Chmod(perm)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("create file %s: %w", path, err)
+	}
+	logf(ctx, "Created file: %s\n", path)
+	return nil
+}
+
+// WriteFile ensures the parent directory exists, then atomically replaces
+// the file at path with the provided content: it is written to a temp file in
+// the same directory, synced and renamed over path, so an interrupted run
+// leaves either the old file or the new one. The file ends up with exactly
+// the permission asked for, even if it existed before; with KeepPerm an
+// existing file keeps its mode. A symlink at path is written through, as the
+// containment checks allowed its target. A cancellation before the rename
+// leaves the old file in place.
+func (o *OSFileSystem) WriteFile(ctx context.Context, path string, content []byte, permission FilePerm) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	// Ensure parent directory exists
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, DefaultFolderPermission); err != nil {
 		return fmt.Errorf("mkdir parent %s: %w", dir, err)
 	}
-	// Write file content
-	if err := os.WriteFile(path, content, permission); err != nil {
+
+	target := path
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		if target, err = filepath.EvalSymlinks(path); err != nil {
+			return fmt.Errorf("resolve symlink %s: %w", path, err)
+		}
+	}
+	perm, set := permission.Get()
+	if !set {
+		perm = DefaultFilePermission
+		if info, err := os.Stat(target); err == nil {
+			perm = info.Mode().Perm()
+		}
+	}
+
+	if err := writeAtomic(ctx, target, content, perm); err != nil {
 		return fmt.Errorf("write file %s: %w", path, err)
 	}
-	fmt.Printf("Wrote file: %s\n", path)
+	logf(ctx, "Wrote file: %s\n", path)
+	return nil
+}
+
+// writeAtomic writes content to a temp file next to path, then renames it
+// over path. The temp file is removed if anything fails.
+func writeAtomic(ctx context.Context, path string, content []byte, permission os.FileMode) (err error) {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+tempFileMarker+"*"+tempFileSuffix)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = tmp.Write(content); err != nil {
+		return err
+	}
+	// CreateTemp uses 0600; chmod also ignores the umask
+	if err = tmp.Chmod(permission); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	syncDir(dir)
+	return nil
+}
+
+// syncDir makes a rename in dir durable. It is best effort: not every
+// platform can open or sync a directory.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
+
+// Temp files of WriteFile are named ".<name>.fgdir-<random>.tmp".
+const (
+	tempFileMarker = ".fgdir-"
+	tempFileSuffix = ".tmp"
+)
+
+// isTempFile reports whether name is a temp file left by WriteFile.
+func isTempFile(name string) bool {
+	return strings.HasPrefix(name, ".") && strings.Contains(name, tempFileMarker) && strings.HasSuffix(name, tempFileSuffix)
+}
+
+// RemoveTempFiles deletes the temp files an interrupted run of cfg left
+// under root, and returns how many it removed. Only the directories the spec
+// writes into are looked at: root, every dir node and everything below a
+// copy node. Other directories, and symlinks, are never entered, so the cost
+// is one directory listing per dir node however big root is. Missing
+// directories are skipped, and so are those the builder would refuse: an
+// invalid name, or a path resolving outside root. A spec that does not
+// expand cleans nothing; building it reports why.
+func RemoveTempFiles(cfg *config.Config, root string) (int, error) {
+	cfg, err := cfg.Expand()
+	if err != nil {
+		return 0, nil
+	}
+	profile, err := utils.PortabilityProfile(cfg.Portability)
+	if err != nil {
+		return 0, nil
+	}
+
+	removed := 0
+	var clean func(nodes []config.StructureNode, dir string) error
+	clean = func(nodes []config.StructureNode, dir string) error {
+		n, err := removeTempFilesIn(dir)
+		removed += n
+		if err != nil {
+			return err
+		}
+		for _, node := range nodes {
+			if node.Type != config.TypeDir && node.Type != config.TypeCopy {
+				continue
+			}
+			path := filepath.Join(dir, node.Name)
+			if profile.ValidateName(node.Name) != nil {
+				continue
+			}
+			if _, err := utils.SanitizeWritePath(root, path); err != nil {
+				continue
+			}
+			if node.Type == config.TypeDir {
+				err = clean(node.Children, path)
+			} else {
+				n, err = removeTempFilesBelow(path)
+				removed += n
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	err = clean(cfg.Structure, root)
+	return removed, err
+}
+
+// removeTempFilesIn deletes the temp files directly in dir.
+func removeTempFilesIn(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) || errors.Is(err, syscall.ENOTDIR) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, e := range entries {
+		if e.Type().IsRegular() && isTempFile(e.Name()) {
+			if err := os.Remove(filepath.Join(dir, e.Name())); err != nil {
+				return removed, err
+			}
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// removeTempFilesBelow deletes the temp files in the tree at root, the
+// target of a copy node. Symlinks are not followed.
+func removeTempFilesBelow(root string) (int, error) {
+	removed := 0
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root && errors.Is(err, fs.ErrNotExist) {
+				return filepath.SkipAll
+			}
+			return err
+		}
+		if d.IsDir() {
+			n, err := removeTempFilesIn(path)
+			removed += n
+			return err
+		}
+		return nil
+	})
+	return removed, err
+}
+
+// CreateSymlink ensures the parent directory exists, then creates a symlink at
+// path pointing to target. An existing symlink at path is replaced; any other
+// existing file is left alone and reported as an error.
+func (o *OSFileSystem) CreateSymlink(ctx context.Context, target, path string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, DefaultFolderPermission); err != nil {
+		return fmt.Errorf("mkdir parent %s: %w", dir, err)
+	}
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSymlink == 0 {
+			return fmt.Errorf("symlink %s: a file already exists there", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("replace symlink %s: %w", path, err)
+		}
+	}
+	if err := os.Symlink(target, path); err != nil {
+		return fmt.Errorf("symlink %s: %w", path, err)
+	}
+	logf(ctx, "Linked: %s -> %s\n", path, target)
+	return nil
+}
+
+// SetPermission sets the permission of an existing file or folder, whatever
+// the umask.
+func (o *OSFileSystem) SetPermission(ctx context.Context, path string, permission os.FileMode) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := os.Chmod(path, permission); err != nil {
+		return fmt.Errorf("chmod %s: %w", path, err)
+	}
 	return nil
 }
 
 // Define default permissions as constant for clarity
 // Default permission 0755 (Owner: rwx, Group/Others: r-x)
-// Fefault permission 0644 (Owner: read/write, Group/Others: read only)
+// Default permission 0644 (Owner: read/write, Group/Others: read only)
+// Executable permission 0755, for scripts starting with a shebang
 const (
-	DefaultFolderPermission os.FileMode = 0755
-	DefaultFilePermission   os.FileMode = 0644
+	DefaultFolderPermission  os.FileMode = 0755
+	DefaultFilePermission    os.FileMode = 0644
+	ExecutableFilePermission os.FileMode = 0755
 )
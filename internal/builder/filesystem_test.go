@@ -0,0 +1,514 @@
+//go:build !windows
+
+package builder_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/KoHorizon/ForgeDir/internal/builder"
+	"github.com/KoHorizon/ForgeDir/internal/config"
+)
+
+func TestStructureBuilder_HonoursModes(t *testing.T) {
+	// modes must not depend on the caller's umask
+	old := syscall.Umask(0077)
+	defer syscall.Umask(old)
+
+	root := t.TempDir()
+	cfg := &config.Config{
+		Structure: []config.StructureNode{
+			{Type: config.TypeDir, Name: "scripts", Mode: "0750", Children: []config.StructureNode{
+				{Type: config.TypeFile, Name: "build.sh", Mode: "0755"},
+			}},
+			{Type: config.TypeFile, Name: "secret.env", Mode: "600"},
+			{Type: config.TypeFile, Name: "README.md"},
+		},
+	}
+	if err := builder.NewStructureBuilder(builder.NewOSFileSystem()).Build(context.Background(), cfg, root); err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+
+	want := map[string]os.FileMode{
+		"scripts":          0750,
+		"scripts/build.sh": 0755,
+		"secret.env":       0600,
+		"README.md":        builder.DefaultFilePermission,
+	}
+	for rel, mode := range want {
+		info, err := os.Stat(filepath.Join(root, filepath.FromSlash(rel)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode().Perm(); got != mode {
+			t.Errorf("%s: expected mode %o, got %o", rel, mode, got)
+		}
+	}
+}
+
+func TestOSFileSystem_CreateFolderKeepsParentModes(t *testing.T) {
+	old := syscall.Umask(0)
+	defer syscall.Umask(old)
+
+	out := filepath.Join(t.TempDir(), "out")
+	if err := builder.NewOSFileSystem().CreateFolder(context.Background(), filepath.Join(out, "private"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	for path, mode := range map[string]os.FileMode{out: builder.DefaultFolderPermission, filepath.Join(out, "private"): 0700} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode().Perm(); got != mode {
+			t.Errorf("%s: expected mode %o, got %o", filepath.Base(path), mode, got)
+		}
+	}
+}
+
+func TestStructureBuilder_ReadOnlyFolders(t *testing.T) {
+	old := syscall.Umask(0022)
+	defer syscall.Umask(old)
+
+	out := filepath.Join(t.TempDir(), "out")
+	t.Cleanup(func() { // let the test directory be removed
+		filepath.WalkDir(out, func(p string, d os.DirEntry, err error) error {
+			if err == nil && d.IsDir() {
+				os.Chmod(p, 0755)
+			}
+			return nil
+		})
+	})
+	cfg := &config.Config{
+		Structure: []config.StructureNode{
+			{Type: config.TypeDir, Name: "ro", Mode: "0555", Children: []config.StructureNode{
+				{Type: config.TypeFile, Name: "a.txt"},
+				{Type: config.TypeDir, Name: "sub", Mode: "0555", Children: []config.StructureNode{
+					{Type: config.TypeFile, Name: "b.txt"},
+				}},
+			}},
+		},
+	}
+	sb := builder.NewStructureBuilder(builder.NewOSFileSystem())
+	if err := sb.BuildWritable(context.Background(), cfg, out); err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+
+	// the generators can still write into the folders
+	mode := func(rel string) os.FileMode {
+		info, err := os.Stat(filepath.Join(out, filepath.FromSlash(rel)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info.Mode().Perm()
+	}
+	if got := mode("ro/sub"); got != 0755 {
+		t.Errorf("expected ro/sub to stay writable until its mode is applied, got %o", got)
+	}
+
+	if err := sb.ApplyFolderModes(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]os.FileMode{".": builder.DefaultFolderPermission, "ro": 0555, "ro/sub": 0555}
+	for rel, perm := range want {
+		if got := mode(rel); got != perm {
+			t.Errorf("%s: expected mode %o, got %o", rel, perm, got)
+		}
+	}
+	for _, rel := range []string{"ro/a.txt", "ro/sub/b.txt"} {
+		if _, err := os.Stat(filepath.Join(out, filepath.FromSlash(rel))); err != nil {
+			t.Errorf("expected %s to be created: %v", rel, err)
+		}
+	}
+}
+
+func TestStructureBuilder_KeepsExistingFiles(t *testing.T) {
+	root := t.TempDir()
+	for name, content := range map[string]string{"README.md": "# docs", "run.sh": "#!/bin/sh\n"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := &config.Config{
+		Structure: []config.StructureNode{
+			{Type: config.TypeFile, Name: "README.md"},
+			{Type: config.TypeFile, Name: "run.sh", Mode: "0755"},
+		},
+	}
+	if err := builder.NewStructureBuilder(builder.NewOSFileSystem()).Build(context.Background(), cfg, root); err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+
+	for name, content := range map[string]string{"README.md": "# docs", "run.sh": "#!/bin/sh\n"} {
+		if got, _ := os.ReadFile(filepath.Join(root, name)); string(got) != content {
+			t.Errorf("%s: expected the content to be kept, got %q", name, got)
+		}
+	}
+	// a declared mode still applies
+	if info, _ := os.Stat(filepath.Join(root, "run.sh")); info.Mode().Perm() != 0755 {
+		t.Errorf("run.sh: expected mode 755, got %o", info.Mode().Perm())
+	}
+	if info, _ := os.Stat(filepath.Join(root, "README.md")); info.Mode().Perm() != 0644 {
+		t.Errorf("README.md: expected mode 644 to be kept, got %o", info.Mode().Perm())
+	}
+}
+
+func TestOSFileSystem_WriteFileResetsMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.sh")
+	fs := builder.NewOSFileSystem()
+	if err := fs.WriteFile(context.Background(), path, nil, builder.WithPerm(0644)); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.WriteFile(context.Background(), path, []byte("#!/bin/sh\n"), builder.WithPerm(0755)); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info.Mode().Perm(); got != 0755 {
+		t.Errorf("expected an existing file to get the new mode 0755, got %o", got)
+	}
+}
+
+func TestOSFileSystem_WriteFileKeepsModeWhenAskedForNone(t *testing.T) {
+	old := syscall.Umask(0077)
+	defer syscall.Umask(old)
+
+	dir := t.TempDir()
+	fs := builder.NewOSFileSystem()
+	fresh := filepath.Join(dir, "fresh.txt")
+	if err := fs.WriteFile(context.Background(), fresh, []byte("new"), builder.KeepPerm); err != nil {
+		t.Fatal(err)
+	}
+	existing := filepath.Join(dir, "tool.sh")
+	if err := os.WriteFile(existing, []byte("old"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(existing, 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.WriteFile(context.Background(), existing, []byte("new"), builder.KeepPerm); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, mode := range map[string]os.FileMode{fresh: builder.DefaultFilePermission, existing: 0750} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode().Perm(); got != mode {
+			t.Errorf("%s: expected mode %o, got %o", filepath.Base(path), mode, got)
+		}
+		if got, _ := os.ReadFile(path); string(got) != "new" {
+			t.Errorf("%s: unexpected content %q", filepath.Base(path), got)
+		}
+	}
+}
+
+func TestOSFileSystem_WriteFileIsAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := builder.NewOSFileSystem().WriteFile(context.Background(), path, []byte("package main\n"), builder.WithPerm(0644)); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if os.SameFile(before, after) {
+		t.Error("expected the file to be replaced by a rename, not rewritten in place")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected only main.go in the directory, got %v", entries)
+	}
+}
+
+func TestOSFileSystem_WriteFileFollowsSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "shared.yml")
+	if err := os.WriteFile(target, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "config.yml")
+	if err := os.Symlink("shared.yml", link); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := builder.NewOSFileSystem().WriteFile(context.Background(), link, []byte("new"), builder.KeepPerm); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := os.Readlink(link); err != nil || got != "shared.yml" {
+		t.Errorf("expected the link to be kept, got %q (%v)", got, err)
+	}
+	if got, _ := os.ReadFile(target); string(got) != "new" {
+		t.Errorf("expected the link target to be written, got %q", got)
+	}
+	if info, _ := os.Stat(target); info.Mode().Perm() != 0600 {
+		t.Errorf("expected the target to keep mode 600, got %o", info.Mode().Perm())
+	}
+}
+
+func TestRemoveTempFiles(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]bool{ // path -> whether it is a stray temp file
+		".main.go.fgdir-123.tmp":          true,
+		"src/.handler.go.fgdir-9.tmp":     true,
+		"src/handler.go":                  false,
+		".env.tmp":                        false,
+		"notes.fgdir-1.tmp":               false,
+		"assets/deep/.x.png.fgdir-42.tmp": true,
+		".git/.index.fgdir-1.tmp":         false,
+		"vendor/.x.go.fgdir-42a.tmp":      false,
+		"src/other/.y.go.fgdir-7.tmp":     false,
+	}
+	for rel := range files {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// only the directories the spec writes into are cleaned
+	cfg := &config.Config{
+		Structure: []config.StructureNode{
+			{Type: config.TypeFile, Name: "main.go"},
+			{Type: config.TypeDir, Name: "src", Children: []config.StructureNode{
+				{Type: config.TypeFile, Name: "handler.go"},
+			}},
+			{Type: config.TypeCopy, Name: "assets", From: "static"},
+			{Type: config.TypeDir, Name: "docs"},
+		},
+	}
+	removed, err := builder.RemoveTempFiles(cfg, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 3 {
+		t.Errorf("expected 3 files removed, got %d", removed)
+	}
+	for rel, stray := range files {
+		_, err := os.Stat(filepath.Join(root, filepath.FromSlash(rel)))
+		if exists := err == nil; exists == stray {
+			t.Errorf("%s: expected exists=%v", rel, !stray)
+		}
+	}
+
+	if _, err := builder.RemoveTempFiles(cfg, filepath.Join(root, "missing")); err != nil {
+		t.Errorf("expected a missing root to be fine, got %v", err)
+	}
+}
+
+func TestRemoveTempFiles_StaysInsideRoot(t *testing.T) {
+	base := t.TempDir()
+	root := filepath.Join(base, "out")
+	victim := filepath.Join(base, "victim")
+	stray := filepath.Join(victim, ".data.fgdir-123.tmp")
+	if err := os.MkdirAll(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(victim, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(stray, []byte("keep"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"internal", "assets"} {
+		if err := os.Symlink(victim, filepath.Join(root, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &config.Config{
+		Structure: []config.StructureNode{
+			{Type: config.TypeDir, Name: "../victim"},
+			{Type: config.TypeDir, Name: "internal"},
+			{Type: config.TypeCopy, Name: "assets", From: "static"},
+		},
+	}
+	removed, err := builder.RemoveTempFiles(cfg, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 0 {
+		t.Errorf("expected nothing removed, got %d", removed)
+	}
+	if _, err := os.Stat(stray); err != nil {
+		t.Errorf("a file outside the output was removed: %v", err)
+	}
+
+	// a spec that does not expand is left to the builder to report
+	bad := &config.Config{Structure: []config.StructureNode{{Type: config.TypeDir, Name: "{{ .Oops"}}}
+	if _, err := builder.RemoveTempFiles(bad, root); err != nil {
+		t.Errorf("expected no error for a spec that does not expand, got %v", err)
+	}
+}
+
+func TestStructureBuilder_RejectsInvalidMode(t *testing.T) {
+	fs := &trackingFS{}
+	cfg := &config.Config{
+		Structure: []config.StructureNode{
+			{Type: config.TypeFile, Name: "ok.txt"},
+			{Type: config.TypeFile, Name: "run.sh", Mode: "rwx"},
+		},
+	}
+	if err := builder.NewStructureBuilder(fs).Build(context.Background(), cfg, t.TempDir()); err == nil {
+		t.Fatal("expected invalid mode to be rejected")
+	}
+	if len(fs.WrittenFiles) != 0 {
+		t.Errorf("expected nothing to be written, got %v", fs.WrittenFiles)
+	}
+}
+
+func TestStructureBuilder_CreatesSymlinks(t *testing.T) {
+	root := t.TempDir()
+	cfg := &config.Config{
+		Structure: []config.StructureNode{
+			{Type: config.TypeFile, Name: ".golangci.yml"},
+			{Type: config.TypeDir, Name: "v2"},
+			{Type: config.TypeSymlink, Name: "current", Target: "v2"},
+			{Type: config.TypeDir, Name: "svc", Children: []config.StructureNode{
+				{Type: config.TypeSymlink, Name: ".golangci.yml", Target: "../.golangci.yml"},
+			}},
+		},
+	}
+	fs := builder.NewOSFileSystem()
+	for i := 0; i < 2; i++ { // building again replaces the links
+		if err := builder.NewStructureBuilder(fs).Build(context.Background(), cfg, root); err != nil {
+			t.Fatalf("build %d failed: %v", i+1, err)
+		}
+	}
+
+	if got, err := os.Readlink(filepath.Join(root, "current")); err != nil || got != "v2" {
+		t.Errorf("expected current -> v2, got %q (%v)", got, err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "svc", ".golangci.yml")); err != nil {
+		t.Errorf("expected svc/.golangci.yml to resolve: %v", err)
+	}
+}
+
+func TestOSFileSystem_SymlinkDoesNotReplaceFiles(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "config.yml")
+	if err := os.WriteFile(path, []byte("keep"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := builder.NewOSFileSystem().CreateSymlink(context.Background(), "other.yml", path); err == nil {
+		t.Fatal("expected an error when a regular file is in the way")
+	}
+	if got, _ := os.ReadFile(path); string(got) != "keep" {
+		t.Errorf("existing file was modified: %q", got)
+	}
+}
+
+func TestStructureBuilder_CopiesFilesVerbatim(t *testing.T) {
+	src := t.TempDir()
+	binary := []byte{0x89, 'P', 'N', 'G', 0x00, '{', '{', ' ', '.', 'X', ' ', '}', '}'}
+	files := map[string][]byte{
+		"logo.png":            binary,
+		"wrapper/gradlew":     []byte("#!/bin/sh\n"),
+		"wrapper/lib/app.jar": {0x50, 0x4b, 0x03, 0x04},
+		"wrapper/lib/.keep":   nil,
+	}
+	for rel, content := range files {
+		path := filepath.Join(src, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chmod(filepath.Join(src, "wrapper", "gradlew"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	root := t.TempDir()
+	cfg := &config.Config{
+		Structure: []config.StructureNode{
+			{Type: config.TypeDir, Name: "static", Children: []config.StructureNode{
+				{Type: config.TypeCopy, Name: "favicon.png", From: filepath.Join(src, "logo.png")},
+			}},
+			{Type: config.TypeCopy, Name: "gradle", From: filepath.Join(src, "wrapper")},
+			{Type: config.TypeCopy, Name: "private.png", From: filepath.Join(src, "logo.png"), Mode: "0600"},
+		},
+	}
+	if err := builder.NewStructureBuilder(builder.NewOSFileSystem()).Build(context.Background(), cfg, root); err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+
+	want := map[string][]byte{
+		"static/favicon.png": binary,
+		"gradle/gradlew":     files["wrapper/gradlew"],
+		"gradle/lib/app.jar": files["wrapper/lib/app.jar"],
+		"gradle/lib/.keep":   nil,
+		"private.png":        binary,
+	}
+	for rel, content := range want {
+		got, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(rel)))
+		if err != nil {
+			t.Fatalf("%s: %v", rel, err)
+		}
+		if string(got) != string(content) {
+			t.Errorf("%s: expected %q, got %q", rel, content, got)
+		}
+	}
+
+	modes := map[string]os.FileMode{"gradle/gradlew": 0755, "gradle/lib/app.jar": 0644, "private.png": 0600}
+	for rel, mode := range modes {
+		info, err := os.Stat(filepath.Join(root, filepath.FromSlash(rel)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode().Perm(); got != mode {
+			t.Errorf("%s: expected mode %o, got %o", rel, mode, got)
+		}
+	}
+}
+
+func TestStructureBuilder_CopyRefusesSymlinks(t *testing.T) {
+	src := t.TempDir()
+	if err := os.Symlink("/etc/passwd", filepath.Join(src, "passwd")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		node config.StructureNode
+	}{
+		{name: "symlink inside a copied directory", node: config.StructureNode{Type: config.TypeCopy, Name: "data", From: src}},
+		{name: "symlink as the source", node: config.StructureNode{Type: config.TypeCopy, Name: "passwd", From: filepath.Join(src, "passwd")}},
+		{name: "missing source", node: config.StructureNode{Type: config.TypeCopy, Name: "gone", From: filepath.Join(src, "gone")}},
+		{name: "no source", node: config.StructureNode{Type: config.TypeCopy, Name: "empty"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			cfg := &config.Config{Structure: []config.StructureNode{tt.node}}
+			if err := builder.NewStructureBuilder(builder.NewOSFileSystem()).Build(context.Background(), cfg, root); err == nil {
+				t.Fatal("expected the copy to fail")
+			}
+			if _, err := os.Lstat(filepath.Join(root, tt.node.Name, "passwd")); err == nil {
+				t.Error("symlink was copied into the project")
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package builder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Kinds of journal entries
+const (
+	CreatedFolder  = "created folder"
+	CreatedFile    = "created file"
+	OverwroteFile  = "overwrote file"
+	CreatedSymlink = "created symlink"
+)
+
+// JournalEntry is one change a Journal saw succeed.
+type JournalEntry struct {
+	Kind string
+	Path string
+}
+
+// Journal is a FileSystem that records every change the one it wraps makes,
+// so an interrupted run can report exactly what it left on disk. A path has
+// one entry however often it is written, the one of its first change: a file
+// the builder created and a generator then filled is a created file, and only
+// a file that existed before the run is an overwritten one. It is safe for
+// concurrent use.
+type Journal struct {
+	fs       FileSystem
+	mu       sync.Mutex
+	entries  []JournalEntry
+	recorded map[string]bool
+}
+
+// NewJournal returns a Journal writing through fs.
+func NewJournal(fs FileSystem) *Journal {
+	return &Journal{fs: fs, recorded: map[string]bool{}}
+}
+
+// Entries returns the changes recorded so far, in the order they were made.
+func (j *Journal) Entries() []JournalEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]JournalEntry(nil), j.entries...)
+}
+
+// CreateFolder creates the folder and records it and every missing parent
+// it brought into existence; folders that already existed are not recorded.
+func (j *Journal) CreateFolder(ctx context.Context, path string, permission os.FileMode) error {
+	missing := missingFolders(path)
+	err := j.fs.CreateFolder(ctx, path, permission)
+	j.recordFolders(missing)
+	return err
+}
+
+// CreateFile creates the file and records it if it was not there yet, after
+// the parent folders it created.
+func (j *Journal) CreateFile(ctx context.Context, path string, permission FilePerm) error {
+	missing := missingFolders(filepath.Dir(path))
+	_, statErr := os.Lstat(path)
+	err := j.fs.CreateFile(ctx, path, permission)
+	j.recordFolders(missing)
+	if err != nil {
+		return err
+	}
+	if os.IsNotExist(statErr) {
+		j.record(CreatedFile, path)
+	}
+	return nil
+}
+
+// WriteFile writes the file and records whether it was created or replaced,
+// after the parent folders it created. A file the journal already recorded
+// is not recorded again.
+func (j *Journal) WriteFile(ctx context.Context, path string, content []byte, permission FilePerm) error {
+	missing := missingFolders(filepath.Dir(path))
+	kind := CreatedFile
+	if _, err := os.Lstat(path); err == nil {
+		kind = OverwroteFile
+	}
+	err := j.fs.WriteFile(ctx, path, content, permission)
+	j.recordFolders(missing)
+	if err != nil {
+		return err
+	}
+	j.record(kind, path)
+	return nil
+}
+
+// CreateSymlink creates the link and records it, after the parent folders it
+// created.
+func (j *Journal) CreateSymlink(ctx context.Context, target, path string) error {
+	missing := missingFolders(filepath.Dir(path))
+	err := j.fs.CreateSymlink(ctx, target, path)
+	j.recordFolders(missing)
+	if err != nil {
+		return err
+	}
+	j.record(CreatedSymlink, path)
+	return nil
+}
+
+// SetPermission sets the permission. A mode change is not recorded: only the
+// paths it applies to tell what the run left on disk.
+func (j *Journal) SetPermission(ctx context.Context, path string, permission os.FileMode) error {
+	return j.fs.SetPermission(ctx, path, permission)
+}
+
+// missingFolders returns path and its parents that do not exist yet,
+// outermost first.
+func missingFolders(path string) []string {
+	var missing []string
+	for p := filepath.Clean(path); ; p = filepath.Dir(p) {
+		if _, err := os.Lstat(p); !os.IsNotExist(err) {
+			break
+		}
+		missing = append([]string{p}, missing...)
+		if filepath.Dir(p) == p {
+			break
+		}
+	}
+	return missing
+}
+
+// recordFolders records the folders of missing that exist now. They are
+// checked even when the call failed, as it may have failed after creating
+// some of them.
+func (j *Journal) recordFolders(missing []string) {
+	for _, p := range missing {
+		if _, err := os.Lstat(p); err == nil {
+			j.record(CreatedFolder, p)
+		}
+	}
+}
+
+// record adds an entry for path, unless it already has one.
+func (j *Journal) record(kind, path string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.recorded[path] {
+		return
+	}
+	j.recorded[path] = true
+	j.entries = append(j.entries, JournalEntry{Kind: kind, Path: path})
+}
@@ -0,0 +1,183 @@
+//go:build !windows
+
+package builder_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/KoHorizon/ForgeDir/internal/builder"
+	"github.com/KoHorizon/ForgeDir/internal/config"
+)
+
+// cancellingFS cancels the build once it has written a number of files, as a
+// Ctrl-C in the middle of a run would.
+type cancellingFS struct {
+	builder.FileSystem
+	cancel context.CancelFunc
+	after  int
+}
+
+func (c *cancellingFS) CreateFile(ctx context.Context, path string, perm builder.FilePerm) error {
+	if err := c.FileSystem.CreateFile(ctx, path, perm); err != nil {
+		return err
+	}
+	if c.after--; c.after == 0 {
+		c.cancel()
+	}
+	return nil
+}
+
+func flatFiles(names ...string) *config.Config {
+	cfg := &config.Config{}
+	for _, name := range names {
+		cfg.Structure = append(cfg.Structure, config.StructureNode{Type: config.TypeFile, Name: name})
+	}
+	return cfg
+}
+
+func TestStructureBuilder_CancelledBeforeStart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	fs := &trackingFS{}
+	err := builder.NewStructureBuilder(fs).Build(ctx, flatFiles("a.txt", "b.txt"), t.TempDir())
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if len(fs.WrittenFiles) != 0 || len(fs.CreatedFolders) != 0 {
+		t.Errorf("expected nothing to be written, got %v and %v", fs.CreatedFolders, fs.WrittenFiles)
+	}
+}
+
+func TestStructureBuilder_StopsWhenCancelled(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "out")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	journal := builder.NewJournal(&cancellingFS{FileSystem: builder.NewOSFileSystem(), cancel: cancel, after: 2})
+	err := builder.NewStructureBuilder(journal).Build(ctx, flatFiles("a.txt", "b.txt", "c.txt", "d.txt"), root)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	want := []builder.JournalEntry{
+		{Kind: builder.CreatedFolder, Path: root},
+		{Kind: builder.CreatedFile, Path: filepath.Join(root, "a.txt")},
+		{Kind: builder.CreatedFile, Path: filepath.Join(root, "b.txt")},
+	}
+	got := journal.Entries()
+	if len(got) != len(want) {
+		t.Fatalf("expected journal %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+	for _, name := range []string{"c.txt", "d.txt"} {
+		if _, err := os.Lstat(filepath.Join(root, name)); !os.IsNotExist(err) {
+			t.Errorf("%s was written after the build was cancelled", name)
+		}
+	}
+}
+
+func TestJournal_RecordsChanges(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "README.md"), []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	journal := builder.NewJournal(builder.NewOSFileSystem())
+
+	steps := []error{
+		journal.CreateFolder(ctx, filepath.Join(root, "a", "b"), builder.DefaultFolderPermission),
+		journal.CreateFolder(ctx, filepath.Join(root, "a"), builder.DefaultFolderPermission),
+		journal.WriteFile(ctx, filepath.Join(root, "a", "b", "main.go"), nil, builder.KeepPerm),
+		journal.WriteFile(ctx, filepath.Join(root, "README.md"), []byte("new"), builder.KeepPerm),
+		journal.CreateSymlink(ctx, "a", filepath.Join(root, "current")),
+	}
+	for i, err := range steps {
+		if err != nil {
+			t.Fatalf("step %d failed: %v", i+1, err)
+		}
+	}
+
+	want := []builder.JournalEntry{
+		{Kind: builder.CreatedFolder, Path: filepath.Join(root, "a")},
+		{Kind: builder.CreatedFolder, Path: filepath.Join(root, "a", "b")},
+		{Kind: builder.CreatedFile, Path: filepath.Join(root, "a", "b", "main.go")},
+		{Kind: builder.OverwroteFile, Path: filepath.Join(root, "README.md")},
+		{Kind: builder.CreatedSymlink, Path: filepath.Join(root, "current")},
+	}
+	got := journal.Entries()
+	if len(got) != len(want) {
+		t.Fatalf("expected journal %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestJournal_CollapsesRepeatWrites(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "README.md"), []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	journal := builder.NewJournal(builder.NewOSFileSystem())
+
+	// the builder creates the files, then the generators fill them in
+	main, readme := filepath.Join(root, "main.go"), filepath.Join(root, "README.md")
+	steps := []error{
+		journal.CreateFile(ctx, main, builder.KeepPerm),
+		journal.CreateFile(ctx, readme, builder.KeepPerm),
+		journal.WriteFile(ctx, main, []byte("package main\n"), builder.KeepPerm),
+		journal.WriteFile(ctx, readme, []byte("new"), builder.KeepPerm),
+		journal.WriteFile(ctx, main, []byte("package main\n"), builder.WithPerm(0755)),
+	}
+	for i, err := range steps {
+		if err != nil {
+			t.Fatalf("step %d failed: %v", i+1, err)
+		}
+	}
+
+	want := []builder.JournalEntry{
+		{Kind: builder.CreatedFile, Path: main},
+		{Kind: builder.OverwroteFile, Path: readme},
+	}
+	got := journal.Entries()
+	if len(got) != len(want) {
+		t.Fatalf("expected journal %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestJournal_SkipsFailedChanges(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	root := t.TempDir()
+	journal := builder.NewJournal(builder.NewOSFileSystem())
+	if err := journal.WriteFile(ctx, filepath.Join(root, "a.txt"), []byte("x"), builder.KeepPerm); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if err := journal.CreateFolder(ctx, filepath.Join(root, "dir"), builder.DefaultFolderPermission); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if got := journal.Entries(); len(got) != 0 {
+		t.Errorf("expected an empty journal, got %v", got)
+	}
+	if entries, _ := os.ReadDir(root); len(entries) != 0 {
+		t.Errorf("expected nothing on disk, got %d entries", len(entries))
+	}
+}
@@ -0,0 +1,31 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+type logKey struct{}
+
+// WithLog returns a context under which OSFileSystem prints its log lines
+// ("Wrote file: …") to w instead of standard output. Code writing files
+// concurrently uses it to collect each file's lines and print them in order.
+func WithLog(ctx context.Context, w io.Writer) context.Context {
+	return context.WithValue(ctx, logKey{}, w)
+}
+
+// Log returns where the log lines of ctx go: the writer set by WithLog, or
+// standard output.
+func Log(ctx context.Context) io.Writer {
+	if w, ok := ctx.Value(logKey{}).(io.Writer); ok {
+		return w
+	}
+	return os.Stdout
+}
+
+// logf prints a log line to Log(ctx).
+func logf(ctx context.Context, format string, args ...any) {
+	fmt.Fprintf(Log(ctx), format, args...)
+}
@@ -1,7 +1,10 @@
 package builder
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"path"
 	"path/filepath"
 
 	"github.com/KoHorizon/ForgeDir/internal/config"
@@ -11,7 +14,17 @@ import (
 // StructureBuilder builds a project scaffold.
 type StructureBuilder struct {
 	fs          FileSystem
-	projectRoot string // Store the absolute project root for validation
+	projectRoot string             // Store the absolute project root for validation
+	portability *utils.Portability // path rules of the spec being built
+	output      string             // monorepo project output, counted in path lengths
+	folderModes []folderMode       // modes of new folders, applied once they are filled
+}
+
+// folderMode is the mode a folder created by the builder gets once its
+// content is written.
+type folderMode struct {
+	path string
+	perm os.FileMode
 }
 
 func NewStructureBuilder(fs FileSystem) *StructureBuilder {
@@ -21,7 +34,20 @@ func NewStructureBuilder(fs FileSystem) *StructureBuilder {
 }
 
 // Build uses the Config's Structure tree to instantiate folders & files under root.
-func (b *StructureBuilder) Build(cfg *config.Config, root string) error {
+// It stops with the context's error once ctx is cancelled; what was created
+// until then stays on disk. A folder it creates gets its mode after its
+// children, so a read-only mode still lets them be created.
+func (b *StructureBuilder) Build(ctx context.Context, cfg *config.Config, root string) error {
+	if err := b.BuildWritable(ctx, cfg, root); err != nil {
+		return err
+	}
+	return b.ApplyFolderModes(ctx)
+}
+
+// BuildWritable builds like Build, but leaves every folder it creates
+// writable by its owner, so the generators can fill it. ApplyFolderModes then
+// gives them their modes.
+func (b *StructureBuilder) BuildWritable(ctx context.Context, cfg *config.Config, root string) error {
 	// Store absolute project root for path validation
 	absRoot, err := filepath.Abs(root)
 	if err != nil {
@@ -29,31 +55,81 @@ func (b *StructureBuilder) Build(cfg *config.Config, root string) error {
 	}
 	b.projectRoot = absRoot
 
+	// Drop nodes whose `when` condition is false
+	cfg, err = cfg.Expand()
+	if err != nil {
+		return fmt.Errorf("structure validation failed: %w", err)
+	}
+	b.portability, err = utils.PortabilityProfile(cfg.Portability)
+	if err != nil {
+		return fmt.Errorf("structure validation failed: %w", err)
+	}
+	b.output = cfg.Output
+	b.folderModes = nil
+
 	// Validate the entire structure before creating anything
 	if err := b.validateStructure(cfg.Structure, ""); err != nil {
 		return fmt.Errorf("structure validation failed: %w", err)
 	}
 
-	return b.createNodes(cfg.Structure, root)
+	return b.createNodes(ctx, cfg.Structure, root)
+}
+
+// ApplyFolderModes gives the folders BuildWritable created the modes their
+// nodes ask for, innermost first.
+func (b *StructureBuilder) ApplyFolderModes(ctx context.Context) error {
+	for _, m := range b.folderModes {
+		if err := b.fs.SetPermission(ctx, m.path, m.perm); err != nil {
+			return fmt.Errorf("chmod %q: %w", m.path, err)
+		}
+	}
+	b.folderModes = nil
+	return nil
 }
 
 // validateStructure performs a pre-flight validation of all path names in the structure
 func (b *StructureBuilder) validateStructure(nodes []config.StructureNode, currentPath string) error {
+	// Siblings must not overwrite each other, on any file system
+	if collisions := config.Collisions(nodes); len(collisions) > 0 {
+		return fmt.Errorf("name collision at path '%s': %w", currentPath, collisions[0])
+	}
+
 	for _, node := range nodes {
 		// Validate the node name itself
-		if err := utils.ValidatePath(node.Name); err != nil {
+		if err := b.portability.ValidateName(node.Name); err != nil {
 			return fmt.Errorf("invalid name '%s' at path '%s': %w", node.Name, currentPath, err)
 		}
 
 		// Build the target path for this node
 		targetPath := filepath.Join(currentPath, node.Name)
+		if err := b.portability.ValidateLength(path.Join(b.output, filepath.ToSlash(targetPath))); err != nil {
+			return fmt.Errorf("invalid path '%s': %w", targetPath, err)
+		}
+
+		if _, err := node.Mode.Perm(0); err != nil {
+			return fmt.Errorf("at path '%s': %w", targetPath, err)
+		}
 
 		// Validate that the resulting path would be safe
 		fullPath := filepath.Join(b.projectRoot, targetPath)
-		if _, err := utils.SanitizePath(b.projectRoot, fullPath); err != nil {
+		if _, err := sanitize(node, b.projectRoot, fullPath); err != nil {
 			return fmt.Errorf("unsafe path '%s': %w", targetPath, err)
 		}
 
+		// A symlink must point inside the project
+		if node.Type == config.TypeSymlink {
+			if _, err := utils.ResolveLinkTarget(b.projectRoot, fullPath, node.Target); err != nil {
+				return fmt.Errorf("unsafe symlink '%s': %w", targetPath, err)
+			}
+		}
+
+		// A copy must have something to copy
+		if node.Type == config.TypeCopy {
+			if err := checkCopySource(node.From); err != nil {
+				return fmt.Errorf("invalid copy '%s': %w", targetPath, err)
+			}
+		}
+
 		// Recursively validate children
 		if len(node.Children) > 0 {
 			if err := b.validateStructure(node.Children, targetPath); err != nil {
@@ -64,33 +140,73 @@ func (b *StructureBuilder) validateStructure(nodes []config.StructureNode, curre
 	return nil
 }
 
+// sanitize checks the path of node against the project root. A symlink node
+// replaces a symlink already at its path; every other node is written
+// through it.
+func sanitize(node config.StructureNode, projectRoot, path string) (string, error) {
+	if node.Type == config.TypeSymlink {
+		return utils.SanitizePath(projectRoot, path)
+	}
+	return utils.SanitizeWritePath(projectRoot, path)
+}
+
 // createNodes is the recursive guts of Build.
-func (b *StructureBuilder) createNodes(nodes []config.StructureNode, currPath string) error {
+func (b *StructureBuilder) createNodes(ctx context.Context, nodes []config.StructureNode, currPath string) error {
 	for _, node := range nodes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		// Validate the individual path component (redundant but defensive)
-		if err := utils.ValidatePath(node.Name); err != nil {
+		if err := b.portability.ValidateName(node.Name); err != nil {
 			return fmt.Errorf("invalid path component '%s': %w", node.Name, err)
 		}
 
 		target := filepath.Join(currPath, node.Name)
 
 		// Additional safety check: ensure target is within project root
-		safeTarget, err := utils.SanitizePath(b.projectRoot, target)
+		safeTarget, err := sanitize(node, b.projectRoot, target)
 		if err != nil {
 			return fmt.Errorf("path safety check failed for '%s': %w", target, err)
 		}
 
 		switch node.Type {
 		case config.TypeDir:
-			if err := b.fs.CreateFolder(safeTarget, DefaultFolderPermission); err != nil {
+			perm, err := node.Mode.Perm(DefaultFolderPermission)
+			if err != nil {
+				return fmt.Errorf("%q: %w", target, err)
+			}
+			// the owner can fill the folder until it gets its own mode
+			_, statErr := os.Lstat(safeTarget)
+			if err := b.fs.CreateFolder(ctx, safeTarget, perm|0700); err != nil {
 				return fmt.Errorf("mkdir %q: %w", safeTarget, err)
 			}
-			if err := b.createNodes(node.Children, target); err != nil {
+			if err := b.createNodes(ctx, node.Children, target); err != nil {
 				return err
 			}
+			if os.IsNotExist(statErr) && perm|0700 != perm {
+				b.folderModes = append(b.folderModes, folderMode{path: safeTarget, perm: perm})
+			}
 		case config.TypeFile:
-			if err := b.fs.WriteFile(safeTarget, []byte{}, DefaultFilePermission); err != nil {
-				return fmt.Errorf("touch %q: %w", safeTarget, err)
+			perm := KeepPerm
+			if node.Mode != "" {
+				mode, err := node.Mode.Perm(DefaultFilePermission)
+				if err != nil {
+					return fmt.Errorf("%q: %w", target, err)
+				}
+				perm = WithPerm(mode)
+			}
+			// the generators write the content; an existing file keeps its own
+			if err := b.fs.CreateFile(ctx, safeTarget, perm); err != nil {
+				return fmt.Errorf("create %q: %w", safeTarget, err)
+			}
+		case config.TypeSymlink:
+			if err := b.fs.CreateSymlink(ctx, filepath.FromSlash(node.Target), safeTarget); err != nil {
+				return fmt.Errorf("symlink %q: %w", safeTarget, err)
+			}
+		case config.TypeCopy:
+			if err := b.copyNode(ctx, node.From, safeTarget, node.Mode); err != nil {
+				return fmt.Errorf("copy %q: %w", safeTarget, err)
 			}
 		default:
 			return fmt.Errorf("unknown node type %q for %q", node.Type, node.Name)
@@ -1,6 +1,7 @@
 package builder_test
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
@@ -14,10 +15,11 @@ import (
 type trackingFS struct {
 	CreatedFolders []string
 	WrittenFiles   []string
+	CreatedLinks   []string
 	ShouldFail     bool
 }
 
-func (t *trackingFS) CreateFolder(path string, perm os.FileMode) error {
+func (t *trackingFS) CreateFolder(ctx context.Context, path string, perm os.FileMode) error {
 	t.CreatedFolders = append(t.CreatedFolders, path)
 	if t.ShouldFail {
 		return os.ErrPermission
@@ -25,7 +27,7 @@ func (t *trackingFS) CreateFolder(path string, perm os.FileMode) error {
 	return nil
 }
 
-func (t *trackingFS) WriteFile(path string, content []byte, perm os.FileMode) error {
+func (t *trackingFS) CreateFile(ctx context.Context, path string, perm builder.FilePerm) error {
 	t.WrittenFiles = append(t.WrittenFiles, path)
 	if t.ShouldFail {
 		return os.ErrPermission
@@ -33,6 +35,29 @@ func (t *trackingFS) WriteFile(path string, content []byte, perm os.FileMode) er
 	return nil
 }
 
+func (t *trackingFS) WriteFile(ctx context.Context, path string, content []byte, perm builder.FilePerm) error {
+	t.WrittenFiles = append(t.WrittenFiles, path)
+	if t.ShouldFail {
+		return os.ErrPermission
+	}
+	return nil
+}
+
+func (t *trackingFS) SetPermission(ctx context.Context, path string, perm os.FileMode) error {
+	if t.ShouldFail {
+		return os.ErrPermission
+	}
+	return nil
+}
+
+func (t *trackingFS) CreateSymlink(ctx context.Context, target, path string) error {
+	t.CreatedLinks = append(t.CreatedLinks, path)
+	if t.ShouldFail {
+		return os.ErrPermission
+	}
+	return nil
+}
+
 func TestStructureBuilder_PathTraversalPrevention(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -83,6 +108,67 @@ func TestStructureBuilder_PathTraversalPrevention(t *testing.T) {
 			expectError:   true,
 			errorContains: "path traversal sequences (..) are not allowed",
 		},
+		{
+			name: "templated name rendering to a traversal",
+			structure: []config.StructureNode{
+				{Type: config.TypeFile, Name: `{{ "../evil.txt" }}`},
+			},
+			expectError:   true,
+			errorContains: "path traversal sequences (..) are not allowed",
+		},
+		{
+			name: "templated name rendering to a separator",
+			structure: []config.StructureNode{
+				{Type: config.TypeDir, Name: `{{ print "src" "/" "evil" }}`},
+			},
+			expectError:   true,
+			errorContains: "path separators are not allowed in names",
+		},
+		{
+			name: "symlink to a sibling",
+			structure: []config.StructureNode{
+				{Type: config.TypeDir, Name: "v2"},
+				{Type: config.TypeSymlink, Name: "current", Target: "v2"},
+				{Type: config.TypeDir, Name: "svc", Children: []config.StructureNode{
+					{Type: config.TypeSymlink, Name: ".golangci.yml", Target: "../.golangci.yml"},
+				}},
+			},
+			expectError: false,
+		},
+		{
+			name: "symlink escaping the project",
+			structure: []config.StructureNode{
+				{Type: config.TypeDir, Name: "svc", Children: []config.StructureNode{
+					{Type: config.TypeSymlink, Name: "passwd", Target: "../../etc/passwd"},
+				}},
+			},
+			expectError:   true,
+			errorContains: "symlink target outside project root not allowed",
+		},
+		{
+			name: "symlink with absolute target",
+			structure: []config.StructureNode{
+				{Type: config.TypeSymlink, Name: "passwd", Target: "/etc/passwd"},
+			},
+			expectError:   true,
+			errorContains: "absolute symlink targets are not allowed",
+		},
+		{
+			name: "symlink templated to escape",
+			structure: []config.StructureNode{
+				{Type: config.TypeSymlink, Name: "link", Target: `{{ "../outside" }}`},
+			},
+			expectError:   true,
+			errorContains: "symlink target outside project root not allowed",
+		},
+		{
+			name: "symlink without target",
+			structure: []config.StructureNode{
+				{Type: config.TypeSymlink, Name: "link"},
+			},
+			expectError:   true,
+			errorContains: "symlink target cannot be empty",
+		},
 		{
 			name: "attempt absolute path",
 			structure: []config.StructureNode{
@@ -139,6 +225,35 @@ func TestStructureBuilder_PathTraversalPrevention(t *testing.T) {
 			expectError:   true,
 			errorContains: "path name cannot be empty",
 		},
+		{
+			name: "duplicate file",
+			structure: []config.StructureNode{
+				{Type: config.TypeFile, Name: "main.go"},
+				{Type: config.TypeFile, Name: "main.go"},
+			},
+			expectError:   true,
+			errorContains: "'main.go' is declared twice",
+		},
+		{
+			name: "case collision in a subdirectory",
+			structure: []config.StructureNode{
+				{Type: config.TypeDir, Name: "docs", Children: []config.StructureNode{
+					{Type: config.TypeFile, Name: "README.md"},
+					{Type: config.TypeFile, Name: "Readme.md"},
+				}},
+			},
+			expectError:   true,
+			errorContains: "name collision at path 'docs'",
+		},
+		{
+			name: "file and dir sharing a name",
+			structure: []config.StructureNode{
+				{Type: config.TypeFile, Name: "build"},
+				{Type: config.TypeDir, Name: "build"},
+			},
+			expectError:   true,
+			errorContains: "file 'build' and dir 'build' share a name",
+		},
 	}
 
 	for _, tt := range tests {
@@ -149,7 +264,7 @@ func TestStructureBuilder_PathTraversalPrevention(t *testing.T) {
 			cfg := &config.Config{Structure: tt.structure}
 			root := t.TempDir() // Use a real temporary directory
 
-			err := sb.Build(cfg, root)
+			err := sb.Build(context.Background(), cfg, root)
 
 			if tt.expectError {
 				if err == nil {
@@ -159,7 +274,7 @@ func TestStructureBuilder_PathTraversalPrevention(t *testing.T) {
 					t.Errorf("expected error to contain %q, got %q", tt.errorContains, err.Error())
 				}
 				// Ensure no files were created when validation fails
-				if len(fs.CreatedFolders) > 0 || len(fs.WrittenFiles) > 0 {
+				if len(fs.CreatedFolders) > 0 || len(fs.WrittenFiles) > 0 || len(fs.CreatedLinks) > 0 {
 					t.Error("no files should be created when validation fails")
 				}
 			} else {
@@ -196,7 +311,7 @@ func TestStructureBuilder_PathContainment(t *testing.T) {
 		},
 	}
 
-	err := sb.Build(cfg, tempDir)
+	err := sb.Build(context.Background(), cfg, tempDir)
 	if err != nil {
 		t.Fatalf("build failed: %v", err)
 	}
@@ -257,3 +372,80 @@ func TestStructureBuilder_PathContainment(t *testing.T) {
 		}
 	}
 }
+
+func TestStructureBuilder_PortabilityProfile(t *testing.T) {
+	deep := make([]config.StructureNode, 1)
+	node := &deep[0]
+	for i := 0; i < 30; i++ {
+		*node = config.StructureNode{Type: config.TypeDir, Name: "component", Children: make([]config.StructureNode, 1)}
+		node = &node.Children[0]
+	}
+	*node = config.StructureNode{Type: config.TypeFile, Name: "file.txt"}
+
+	tests := []struct {
+		name          string
+		portability   string
+		output        string // monorepo project output
+		structure     []config.StructureNode
+		errorContains string
+	}{
+		{
+			name:        "posix allows windows-invalid names",
+			portability: "posix",
+			structure:   []config.StructureNode{{Type: config.TypeFile, Name: "what?.md"}, {Type: config.TypeFile, Name: "aux.c"}},
+		},
+		{
+			name:          "portable rejects them",
+			structure:     []config.StructureNode{{Type: config.TypeFile, Name: "aux.c"}},
+			errorContains: "reserved filename not allowed",
+		},
+		{
+			name:          "strict rejects spaces",
+			portability:   "strict",
+			structure:     []config.StructureNode{{Type: config.TypeFile, Name: "my notes.md"}},
+			errorContains: "outside the portable set",
+		},
+		{
+			name:          "windows limits the path length",
+			portability:   "windows",
+			structure:     deep,
+			errorContains: "the windows limit is 259",
+		},
+		{
+			name:        "posix allows long paths",
+			portability: "posix",
+			structure:   deep,
+		},
+		{
+			name:      "a single project has no output",
+			structure: []config.StructureNode{{Type: config.TypeFile, Name: strings.Repeat("a", 250)}},
+		},
+		{
+			name:          "the output counts in the path length of a project",
+			output:        "services/billing",
+			structure:     []config.StructureNode{{Type: config.TypeFile, Name: strings.Repeat("a", 250)}},
+			errorContains: "path is 267 bytes long, the portable limit is 259: services/billing/aaa",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := &trackingFS{}
+			cfg := &config.Config{Portability: tt.portability, Output: tt.output, Structure: tt.structure}
+			err := builder.NewStructureBuilder(fs).Build(context.Background(), cfg, t.TempDir())
+
+			if tt.errorContains == "" {
+				if err != nil {
+					t.Fatalf("expected no error but got: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errorContains) {
+				t.Fatalf("expected error containing %q, got: %v", tt.errorContains, err)
+			}
+			if len(fs.CreatedFolders) > 0 || len(fs.WrittenFiles) > 0 {
+				t.Error("no files should be created when validation fails")
+			}
+		})
+	}
+}
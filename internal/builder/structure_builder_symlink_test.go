@@ -0,0 +1,220 @@
+//go:build !windows
+
+package builder_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KoHorizon/ForgeDir/internal/builder"
+	"github.com/KoHorizon/ForgeDir/internal/config"
+)
+
+// TestStructureBuilder_SymlinksOnDisk builds into output directories that
+// already hold symlinks, with the real file system.
+func TestStructureBuilder_SymlinksOnDisk(t *testing.T) {
+	tests := []struct {
+		name string
+		// setup prepares the project root; outside is a directory next to it
+		setup         func(t *testing.T, root, outside string)
+		structure     []config.StructureNode
+		errorContains string
+	}{
+		{
+			name: "directory symlink escaping the root",
+			setup: func(t *testing.T, root, outside string) {
+				symlink(t, outside, filepath.Join(root, "internal"))
+			},
+			structure: []config.StructureNode{
+				{Type: config.TypeDir, Name: "internal", Children: []config.StructureNode{
+					{Type: config.TypeFile, Name: "passwd"},
+				}},
+			},
+			errorContains: "escapes project root through a symlink",
+		},
+		{
+			name: "nested directory symlink escaping the root",
+			setup: func(t *testing.T, root, outside string) {
+				mkdir(t, filepath.Join(root, "pkg"))
+				symlink(t, "../../outside", filepath.Join(root, "pkg", "api"))
+			},
+			structure: []config.StructureNode{
+				{Type: config.TypeDir, Name: "pkg", Children: []config.StructureNode{
+					{Type: config.TypeDir, Name: "api", Children: []config.StructureNode{
+						{Type: config.TypeFile, Name: "api.go"},
+					}},
+				}},
+			},
+			errorContains: "escapes project root through a symlink",
+		},
+		{
+			name: "file symlink escaping the root",
+			setup: func(t *testing.T, root, outside string) {
+				if err := os.WriteFile(filepath.Join(outside, "victim"), []byte("keep"), 0644); err != nil {
+					t.Fatal(err)
+				}
+				symlink(t, filepath.Join(outside, "victim"), filepath.Join(root, "README.md"))
+			},
+			structure:     []config.StructureNode{{Type: config.TypeFile, Name: "README.md"}},
+			errorContains: "escapes project root through a symlink",
+		},
+		{
+			name: "broken symlink",
+			setup: func(t *testing.T, root, outside string) {
+				symlink(t, filepath.Join(outside, "missing"), filepath.Join(root, "notes.md"))
+			},
+			structure:     []config.StructureNode{{Type: config.TypeFile, Name: "notes.md"}},
+			errorContains: "broken symlink not allowed",
+		},
+		{
+			name: "directory symlink inside the root",
+			setup: func(t *testing.T, root, outside string) {
+				mkdir(t, filepath.Join(root, "v2"))
+				symlink(t, "v2", filepath.Join(root, "current"))
+			},
+			structure: []config.StructureNode{
+				{Type: config.TypeDir, Name: "current", Children: []config.StructureNode{
+					{Type: config.TypeFile, Name: "main.go"},
+				}},
+			},
+		},
+		{
+			name: "symlink node pointing through an escaping symlink",
+			setup: func(t *testing.T, root, outside string) {
+				symlink(t, outside, filepath.Join(root, "shared"))
+			},
+			structure: []config.StructureNode{
+				{Type: config.TypeSymlink, Name: "config", Target: "shared/victim"},
+			},
+			errorContains: "symlink target outside project root not allowed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := t.TempDir()
+			root := filepath.Join(base, "project")
+			outside := filepath.Join(base, "outside")
+			mkdir(t, root)
+			mkdir(t, outside)
+			tt.setup(t, root, outside)
+
+			err := builder.NewStructureBuilder(builder.NewOSFileSystem()).Build(context.Background(), &config.Config{Structure: tt.structure}, root)
+
+			if tt.errorContains == "" {
+				if err != nil {
+					t.Fatalf("expected no error but got: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.errorContains) {
+				t.Fatalf("expected error containing %q, got: %v", tt.errorContains, err)
+			}
+
+			entries, err := os.ReadDir(outside)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, e := range entries {
+				if e.Name() != "victim" {
+					t.Errorf("%s was written outside the project root", e.Name())
+				}
+			}
+			if got, err := os.ReadFile(filepath.Join(outside, "victim")); err == nil && string(got) != "keep" {
+				t.Errorf("a file outside the project root was modified: %q", got)
+			}
+		})
+	}
+}
+
+// TestStructureBuilder_CopyThroughEscapingSymlink copies a directory into
+// one that already holds a symlink leading outside the project.
+func TestStructureBuilder_CopyThroughEscapingSymlink(t *testing.T) {
+	base := t.TempDir()
+	src := filepath.Join(base, "src")
+	mkdir(t, filepath.Join(src, "img"))
+	if err := os.WriteFile(filepath.Join(src, "img", "logo.png"), []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	root := filepath.Join(base, "project")
+	outside := filepath.Join(base, "outside")
+	mkdir(t, filepath.Join(root, "static"))
+	mkdir(t, outside)
+	symlink(t, outside, filepath.Join(root, "static", "img"))
+
+	cfg := &config.Config{
+		Structure: []config.StructureNode{{Type: config.TypeCopy, Name: "static", From: src}},
+	}
+	err := builder.NewStructureBuilder(builder.NewOSFileSystem()).Build(context.Background(), cfg, root)
+	if err == nil || !strings.Contains(err.Error(), "escapes project root through a symlink") {
+		t.Fatalf("expected the copy to be refused, got: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(outside, "logo.png")); err == nil {
+		t.Error("logo.png was copied outside the project root")
+	}
+}
+
+// TestStructureBuilder_SymlinkedRoot builds into a root that is itself a
+// symlink, as /tmp is on macOS.
+func TestStructureBuilder_SymlinkedRoot(t *testing.T) {
+	base := t.TempDir()
+	real := filepath.Join(base, "real")
+	mkdir(t, real)
+	root := filepath.Join(base, "link")
+	symlink(t, real, root)
+
+	cfg := &config.Config{
+		Structure: []config.StructureNode{
+			{Type: config.TypeDir, Name: "src", Children: []config.StructureNode{
+				{Type: config.TypeFile, Name: "main.go"},
+			}},
+		},
+	}
+	if err := builder.NewStructureBuilder(builder.NewOSFileSystem()).Build(context.Background(), cfg, root); err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(real, "src", "main.go")); err != nil {
+		t.Errorf("expected src/main.go in the real directory: %v", err)
+	}
+}
+
+// TestStructureBuilder_RebuildsDanglingSymlink builds twice a symlink node
+// whose target the project does not create, as for a build output.
+func TestStructureBuilder_RebuildsDanglingSymlink(t *testing.T) {
+	root := t.TempDir()
+	cfg := &config.Config{
+		Structure: []config.StructureNode{
+			{Type: config.TypeSymlink, Name: "out", Target: "build/out"},
+		},
+	}
+	for run := 1; run <= 2; run++ {
+		if err := builder.NewStructureBuilder(builder.NewOSFileSystem()).Build(context.Background(), cfg, root); err != nil {
+			t.Fatalf("run %d: build failed: %v", run, err)
+		}
+	}
+	if target, err := os.Readlink(filepath.Join(root, "out")); err != nil || target != "build/out" {
+		t.Errorf("expected out -> build/out, got %q (%v)", target, err)
+	}
+
+	// nothing is written through the dangling link though
+	cfg.Structure = []config.StructureNode{{Type: config.TypeFile, Name: "out"}}
+	err := builder.NewStructureBuilder(builder.NewOSFileSystem()).Build(context.Background(), cfg, root)
+	if err == nil || !strings.Contains(err.Error(), "broken symlink not allowed") {
+		t.Errorf("expected a broken symlink error, got %v", err)
+	}
+}
+
+func symlink(t *testing.T, target, path string) {
+	t.Helper()
+	if err := os.Symlink(target, path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func mkdir(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(path, 0755); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Collision is a node that would be created at the same path as an earlier
+// sibling, on some file system.
+type Collision struct {
+	First, Second StructureNode
+}
+
+func (c Collision) Error() string {
+	a, b := c.First, c.Second
+	switch {
+	case a.Name == b.Name && a.Type != b.Type:
+		return fmt.Sprintf("%s '%s' and %s '%s' share a name", a.Type, a.Name, b.Type, b.Name)
+	case a.Name == b.Name:
+		return fmt.Sprintf("'%s' is declared twice", b.Name)
+	case norm.NFC.String(a.Name) == norm.NFC.String(b.Name):
+		return fmt.Sprintf("'%s' and '%s' differ only in Unicode normalization", a.Name, b.Name)
+	default:
+		return fmt.Sprintf("'%s' and '%s' differ only in case", a.Name, b.Name)
+	}
+}
+
+// Collisions lists the nodes among siblings whose names match an earlier
+// sibling exactly, after Unicode normalization, or ignoring case, as they
+// would on macOS and Windows file systems. A file and a directory with the
+// same name collide too.
+func Collisions(nodes []StructureNode) []Collision {
+	var out []Collision
+	seen := make(map[string]int, len(nodes))
+	for i, n := range nodes {
+		if n.Name == "" {
+			continue
+		}
+		key := FoldName(n.Name)
+		if j, ok := seen[key]; ok {
+			out = append(out, Collision{First: nodes[j], Second: n})
+			continue
+		}
+		seen[key] = i
+	}
+	return out
+}
+
+// FoldName is the form of name that case-insensitive, normalizing file
+// systems compare: NFC normalized and case folded.
+func FoldName(name string) string {
+	return norm.NFC.String(cases.Fold().String(norm.NFC.String(name)))
+}
@@ -0,0 +1,68 @@
+package config_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/KoHorizon/ForgeDir/internal/config"
+)
+
+func TestCollisions(t *testing.T) {
+	file := func(name string) config.StructureNode { return config.StructureNode{Type: config.TypeFile, Name: name} }
+	dir := func(name string) config.StructureNode { return config.StructureNode{Type: config.TypeDir, Name: name} }
+
+	tests := []struct {
+		name  string
+		nodes []config.StructureNode
+		want  []string
+	}{
+		{
+			name:  "distinct names",
+			nodes: []config.StructureNode{file("main.go"), file("main_test.go"), dir("main")},
+		},
+		{
+			name:  "exact duplicate",
+			nodes: []config.StructureNode{file("main.go"), file("util.go"), file("main.go")},
+			want:  []string{"'main.go' is declared twice"},
+		},
+		{
+			name:  "case",
+			nodes: []config.StructureNode{file("README.md"), file("Readme.md"), file("readme.MD")},
+			want: []string{
+				"'README.md' and 'Readme.md' differ only in case",
+				"'README.md' and 'readme.MD' differ only in case",
+			},
+		},
+		{
+			name:  "unicode normalization",
+			nodes: []config.StructureNode{file("café.txt"), file("cafe\u0301.txt")},
+			want:  []string{"'café.txt' and 'cafe\u0301.txt' differ only in Unicode normalization"},
+		},
+		{
+			name:  "normalization and case",
+			nodes: []config.StructureNode{dir("Été"), dir("e\u0301te\u0301")},
+			want:  []string{"'Été' and 'e\u0301te\u0301' differ only in case"},
+		},
+		{
+			name:  "file and dir",
+			nodes: []config.StructureNode{dir("build"), file("build")},
+			want:  []string{"dir 'build' and file 'build' share a name"},
+		},
+		{
+			name:  "empty names are left to name validation",
+			nodes: []config.StructureNode{file(""), file("")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, c := range config.Collisions(tt.nodes) {
+				got = append(got, c.Error())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got collisions:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
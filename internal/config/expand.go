@@ -0,0 +1,207 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"github.com/KoHorizon/ForgeDir/internal/utils"
+)
+
+// Expand returns the literal tree the spec describes for its variables:
+//   - a node with `forEach` is repeated once per item of the list it names;
+//     each copy (and its subtree) gets the item in Item, and the items of
+//     every enclosing loop, outermost first, in Items;
+//   - nodes whose `when` condition is false are dropped with their subtree;
+//   - node names are rendered as templates (.ProjectName, .Language, .Vars,
+//     .Item, .Items), and every rendered name must pass the rules of the spec's
+//     portability profile, so a variable can never inject ".." or a path
+//     separator;
+//   - symlink targets are rendered the same way; their containment is checked
+//     where the project root is known.
+//
+// The builder and the generators expand the config before walking it, so they
+// always agree on the tree; expanding an expanded config returns it unchanged.
+//
+// A node that fails to expand does not stop the others: the error joins
+// every failure, each a *NodeError naming its node, and the tree returned
+// with it leaves the failed nodes out, so a validator can still check the
+// rest.
+func (c *Config) Expand() (*Config, error) {
+	if c.expanded {
+		return c, nil
+	}
+
+	portability, err := utils.PortabilityProfile(c.Portability)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &expander{cfg: c, portability: portability}
+	out := *c
+	out.Structure = e.nodes(c.Structure, nil, "")
+	if len(e.errs) > 0 {
+		return &out, errors.Join(e.errs...)
+	}
+	out.expanded = true
+	return &out, nil
+}
+
+// NodeError is a node of the spec that failed to expand.
+type NodeError struct {
+	Path string   // node path, as in "/handlers/{{ .Item.name }}.go"
+	Pos  Position // where the node is declared
+	Err  error
+}
+
+func (e *NodeError) Error() string { return e.Err.Error() }
+func (e *NodeError) Unwrap() error { return e.Err }
+
+// nameData is what a templated node name can reference.
+type nameData struct {
+	ProjectName string
+	Language    string
+	Vars        map[string]any
+	Item        any
+	Items       []any
+}
+
+type expander struct {
+	cfg         *Config
+	portability *utils.Portability
+	errs        []error
+}
+
+// fail records that n, at nodePath, failed to expand.
+func (e *expander) fail(n StructureNode, nodePath string, format string, args ...any) {
+	e.errs = append(e.errs, &NodeError{Path: nodePath, Pos: n.Pos, Err: fmt.Errorf(format, args...)})
+}
+
+// nodes expands one level of the tree. items holds the forEach items inherited
+// from the enclosing loops, outermost first (empty outside loops); parent is
+// the node path used in errors.
+func (e *expander) nodes(nodes []StructureNode, items []any, parent string) []StructureNode {
+	var out []StructureNode
+	for _, n := range nodes {
+		if n.ForEach == "" {
+			if expanded, ok := e.node(n, items, parent); ok {
+				out = append(out, expanded)
+			}
+			continue
+		}
+
+		list := e.items(n, items, parent)
+		n.ForEach = ""
+		for _, it := range list {
+			// a fresh slice per copy, so siblings never share a backing array
+			inner := append(append(make([]any, 0, len(items)+1), items...), it)
+			if expanded, ok := e.node(n, inner, parent); ok {
+				out = append(out, expanded)
+			}
+		}
+	}
+	return out
+}
+
+// node expands a single node for the given loop items; ok is false when its
+// `when` condition rules it out or it failed to expand.
+func (e *expander) node(n StructureNode, items []any, parent string) (StructureNode, bool) {
+	nodePath := parent + "/" + n.Name
+	scope := exprScope(e.cfg.Variables, items)
+
+	if n.When != "" {
+		ok, err := evalCondition(n.When, scope)
+		if err != nil {
+			e.fail(n, nodePath, "invalid when at %s: %w", nodePath, err)
+			return n, false
+		}
+		if !ok {
+			return n, false
+		}
+		n.When = ""
+	}
+
+	name, err := e.renderName(n.Name, items)
+	if err != nil {
+		e.fail(n, nodePath, "invalid name at %s: %w", nodePath, err)
+		return n, false
+	}
+	if name != n.Name {
+		if err := e.portability.ValidateName(name); err != nil {
+			e.fail(n, nodePath, "invalid name at %s: rendered to %q: %w", nodePath, name, err)
+			return n, false
+		}
+		n.Name = name
+		nodePath = parent + "/" + name
+	}
+	target, err := e.renderName(n.Target, items)
+	if err != nil {
+		e.fail(n, nodePath, "invalid target at %s: %w", nodePath, err)
+		return n, false
+	}
+	n.Target = target
+	n.Item, n.Items = lastItem(items), items
+	n.Children = e.nodes(n.Children, items, nodePath)
+	return n, true
+}
+
+// items evaluates a node's forEach expression, which must name a list; a
+// node whose expression fails gets no copies.
+func (e *expander) items(n StructureNode, items []any, parent string) []any {
+	nodePath := parent + "/" + n.Name
+	v, err := evalExpr(n.ForEach, exprScope(e.cfg.Variables, items))
+	if err != nil {
+		e.fail(n, nodePath, "invalid forEach at %s: %w", nodePath, err)
+		return nil
+	}
+	if v == nil {
+		return nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		e.fail(n, nodePath, "invalid forEach at %s: %s is a %T, not a list", nodePath, n.ForEach, v)
+		return nil
+	}
+	list := make([]any, rv.Len())
+	for i := range list {
+		list[i] = rv.Index(i).Interface()
+	}
+	return list
+}
+
+// renderName executes a node name as a template, with the same helper
+// functions as file templates; names without actions are returned as is.
+func (e *expander) renderName(name string, items []any) (string, error) {
+	if !strings.Contains(name, "{{") {
+		return name, nil
+	}
+
+	tmpl, err := template.New("name").Funcs(TemplateFuncs()).Option("missingkey=error").Parse(name)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, nameData{
+		ProjectName: e.cfg.ProjectName,
+		Language:    e.cfg.Language,
+		Vars:        e.cfg.Variables,
+		Item:        lastItem(items),
+		Items:       items,
+	})
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// lastItem returns the item of the innermost loop, nil outside loops.
+func lastItem(items []any) any {
+	if len(items) == 0 {
+		return nil
+	}
+	return items[len(items)-1]
+}
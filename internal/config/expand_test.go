@@ -0,0 +1,260 @@
+package config_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/KoHorizon/ForgeDir/internal/config"
+)
+
+func TestExpand_ForEach(t *testing.T) {
+	cfg := &config.Config{
+		Variables: map[string]any{
+			"entities": []any{
+				map[string]any{"name": "user", "audited": true},
+				map[string]any{"name": "order", "audited": false},
+			},
+		},
+		Structure: []config.StructureNode{
+			{Type: config.TypeFile, Name: "{{ .Item.name }}_handler.go", ForEach: "vars.entities"},
+			{Type: config.TypeDir, Name: "{{ .Item.name }}", ForEach: "vars.entities", Children: []config.StructureNode{
+				{Type: config.TypeFile, Name: "{{ .Item.name }}_service.go"},
+				{Type: config.TypeFile, Name: "audit.go", When: "item.audited"},
+			}},
+		},
+	}
+
+	got, err := cfg.Expand()
+	if err != nil {
+		t.Fatalf("Expand returned unexpected error: %v", err)
+	}
+
+	var names []string
+	for _, n := range got.Structure {
+		names = append(names, n.Name)
+	}
+	if strings.Join(names, ",") != "user_handler.go,order_handler.go,user,order" {
+		t.Fatalf("unexpected expansion: %v", names)
+	}
+
+	user := got.Structure[2]
+	if len(user.Children) != 2 || user.Children[0].Name != "user_service.go" || user.Children[1].Name != "audit.go" {
+		t.Errorf("unexpected user children: %+v", user.Children)
+	}
+	if item, _ := user.Children[0].Item.(map[string]any); item["name"] != "user" {
+		t.Errorf("expected children to inherit the item, got %v", user.Children[0].Item)
+	}
+	if order := got.Structure[3]; len(order.Children) != 1 {
+		t.Errorf("expected audit.go skipped for order, got %+v", order.Children)
+	}
+}
+
+func TestExpand_NestedForEach(t *testing.T) {
+	cfg := &config.Config{
+		Variables: map[string]any{
+			"services": []any{
+				map[string]any{"name": "billing", "versions": []any{"v1", "v2"}},
+				map[string]any{"name": "auth", "versions": []any{"v1"}},
+			},
+		},
+		Structure: []config.StructureNode{
+			{Type: config.TypeDir, Name: "{{ .Item.name }}", ForEach: "vars.services", Children: []config.StructureNode{
+				{Type: config.TypeFile, Name: "{{ (index .Items 0).name }}_{{ .Item }}.go", ForEach: "item.versions"},
+				{Type: config.TypeDir, Name: "{{ .Item }}", ForEach: "item.versions", Children: []config.StructureNode{
+					{Type: config.TypeFile, Name: "billing_only.go", When: `parent.name == "billing"`},
+				}},
+			}},
+		},
+	}
+
+	got, err := cfg.Expand()
+	if err != nil {
+		t.Fatalf("Expand returned unexpected error: %v", err)
+	}
+
+	var paths []string
+	var walk func(nodes []config.StructureNode, prefix string)
+	walk = func(nodes []config.StructureNode, prefix string) {
+		for _, n := range nodes {
+			paths = append(paths, prefix+n.Name)
+			walk(n.Children, prefix+n.Name+"/")
+		}
+	}
+	walk(got.Structure, "")
+	want := "billing,billing/billing_v1.go,billing/billing_v2.go,billing/v1,billing/v1/billing_only.go,billing/v2,billing/v2/billing_only.go," +
+		"auth,auth/auth_v1.go,auth/v1"
+	if strings.Join(paths, ",") != want {
+		t.Fatalf("unexpected expansion:\n got %v\nwant %v", strings.Join(paths, ","), want)
+	}
+
+	inner := got.Structure[0].Children[2].Children[0]
+	if len(inner.Items) != 2 || inner.Item != "v1" {
+		t.Fatalf("expected the outer and inner items, got %v (item %v)", inner.Items, inner.Item)
+	}
+	if outer, _ := inner.Items[0].(map[string]any); outer["name"] != "billing" {
+		t.Errorf("expected the outer item first, got %v", inner.Items[0])
+	}
+}
+
+func TestExpand_ForEachErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		node          config.StructureNode
+		errorContains string
+	}{
+		{
+			name:          "not a list",
+			node:          config.StructureNode{Type: config.TypeFile, Name: "x", ForEach: "vars.name"},
+			errorContains: "not a list",
+		},
+		{
+			name:          "unknown item key",
+			node:          config.StructureNode{Type: config.TypeFile, Name: "{{ .Item.nme }}.go", ForEach: "vars.entities"},
+			errorContains: "invalid name at /{{ .Item.nme }}.go",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Variables: map[string]any{"name": "svc", "entities": []any{map[string]any{"name": "user"}}},
+				Structure: []config.StructureNode{tt.node},
+			}
+			if _, err := cfg.Expand(); err == nil || !strings.Contains(err.Error(), tt.errorContains) {
+				t.Errorf("expected error containing %q, got %v", tt.errorContains, err)
+			}
+		})
+	}
+}
+
+func TestExpand_CollectsEveryError(t *testing.T) {
+	cfg := &config.Config{
+		Variables: map[string]any{"name": "svc"},
+		Structure: []config.StructureNode{
+			{Type: config.TypeFile, Name: "a.go", When: "vars.name ==", Pos: config.Position{Line: 3}},
+			{Type: config.TypeDir, Name: "ok", Children: []config.StructureNode{
+				{Type: config.TypeFile, Name: "{{ .Oops", Pos: config.Position{Line: 6}},
+				{Type: config.TypeFile, Name: "main.go"},
+			}},
+			{Type: config.TypeFile, Name: "x", ForEach: "vars.name", Pos: config.Position{Line: 9}},
+		},
+	}
+	got, err := cfg.Expand()
+	if err == nil {
+		t.Fatal("expected Expand to fail")
+	}
+
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		t.Fatalf("expected a joined error, got %T", err)
+	}
+	want := []struct {
+		path string
+		line int
+	}{{"/a.go", 3}, {"/ok/{{ .Oops", 6}, {"/x", 9}}
+	errs := joined.Unwrap()
+	if len(errs) != len(want) {
+		t.Fatalf("expected %d errors, got %v", len(want), errs)
+	}
+	for i, w := range want {
+		var nodeErr *config.NodeError
+		if !errors.As(errs[i], &nodeErr) || nodeErr.Path != w.path || nodeErr.Pos.Line != w.line {
+			t.Errorf("error %d: expected %s at line %d, got %#v", i, w.path, w.line, errs[i])
+		}
+	}
+
+	// the nodes that expanded are still there
+	if got == nil || len(got.Structure) != 1 || len(got.Structure[0].Children) != 1 || got.Structure[0].Children[0].Name != "main.go" {
+		t.Errorf("expected only ok/main.go to be left, got %+v", got)
+	}
+}
+
+func TestExpand_TemplatedNames(t *testing.T) {
+	cfg := &config.Config{
+		ProjectName: "orders",
+		Variables:   map[string]any{"module": "billing"},
+		Structure: []config.StructureNode{
+			{Type: config.TypeDir, Name: "cmd", Children: []config.StructureNode{
+				{Type: config.TypeDir, Name: "{{ .ProjectName }}", Children: []config.StructureNode{
+					{Type: config.TypeFile, Name: "main.go"},
+				}},
+			}},
+			{Type: config.TypeFile, Name: "{{ .Vars.module }}.go"},
+		},
+	}
+
+	got, err := cfg.Expand()
+	if err != nil {
+		t.Fatalf("Expand returned unexpected error: %v", err)
+	}
+	if name := got.Structure[0].Children[0].Name; name != "orders" {
+		t.Errorf("expected cmd/orders, got cmd/%s", name)
+	}
+	if name := got.Structure[1].Name; name != "billing.go" {
+		t.Errorf("expected billing.go, got %s", name)
+	}
+}
+
+func TestExpand_TemplatedNamesUseTemplateFuncs(t *testing.T) {
+	cfg := &config.Config{
+		Variables: map[string]any{"entities": []any{"user_service", "order-item"}},
+		Structure: []config.StructureNode{
+			{Type: config.TypeFile, Name: "{{ className .Item }}.java", ForEach: "vars.entities"},
+		},
+	}
+
+	got, err := cfg.Expand()
+	if err != nil {
+		t.Fatalf("Expand returned unexpected error: %v", err)
+	}
+	if len(got.Structure) != 2 || got.Structure[0].Name != "UserService.java" || got.Structure[1].Name != "OrderItem.java" {
+		t.Errorf("expected UserService.java and OrderItem.java, got %+v", got.Structure)
+	}
+}
+
+func TestExpand_TemplatedNamesArePathChecked(t *testing.T) {
+	tests := []struct {
+		value         string
+		errorContains string
+	}{
+		{"../../etc", "path traversal sequences (..) are not allowed"},
+		{"a/b", "path separators are not allowed in names"},
+		{"/etc", "absolute paths are not allowed"},
+		{"  ", "path name cannot be empty"},
+	}
+
+	for _, tt := range tests {
+		cfg := &config.Config{
+			Variables: map[string]any{"dir": tt.value},
+			Structure: []config.StructureNode{{Type: config.TypeDir, Name: "{{ .Vars.dir }}"}},
+		}
+		if _, err := cfg.Expand(); err == nil || !strings.Contains(err.Error(), tt.errorContains) {
+			t.Errorf("%q: expected error containing %q, got %v", tt.value, tt.errorContains, err)
+		}
+	}
+}
+
+func TestExpand_TemplatedNamesFollowPortability(t *testing.T) {
+	cfg := &config.Config{
+		Variables: map[string]any{"name": "notes?"},
+		Structure: []config.StructureNode{{Type: config.TypeFile, Name: "{{ .Vars.name }}"}},
+	}
+	if _, err := cfg.Expand(); err == nil || !strings.Contains(err.Error(), "invalid character '?'") {
+		t.Errorf("portable: expected an invalid character error, got %v", err)
+	}
+
+	cfg.Portability = "posix"
+	expanded, err := cfg.Expand()
+	if err != nil {
+		t.Fatalf("posix: unexpected error: %v", err)
+	}
+	if expanded.Structure[0].Name != "notes?" {
+		t.Errorf("posix: unexpected name %q", expanded.Structure[0].Name)
+	}
+
+	cfg.Portability = "dos"
+	if _, err := cfg.Expand(); err == nil || !strings.Contains(err.Error(), "unknown portability profile") {
+		t.Errorf("expected an unknown profile error, got %v", err)
+	}
+}
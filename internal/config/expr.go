@@ -0,0 +1,399 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Expressions are used by `when:` conditions. The grammar is small:
+//
+//	or      = and { "||" and }
+//	and     = unary { "&&" unary }
+//	unary   = "!" unary | compare
+//	compare = primary [ ("==" | "!=" | "<" | "<=" | ">" | ">=") primary ]
+//	primary = "(" or ")" | string | number | "true" | "false" | "null" | ref
+//	ref     = ("vars" | "item" | "parent") { "." name }
+//
+// "item" is the current forEach item and "parent" the item of the enclosing
+// loop (null outside nested loops). A reference to a missing variable
+// evaluates to null, which is falsy.
+
+// expr is a parsed expression node, evaluated against a scope holding the
+// "vars", "item" and "parent" roots.
+type expr interface {
+	eval(scope map[string]any) any
+}
+
+// parseExpr parses an expression, reporting syntax errors without evaluating it.
+func parseExpr(src string) (expr, error) {
+	p := &exprParser{src: src}
+	if err := p.tokenize(); err != nil {
+		return nil, err
+	}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in expression %q", p.tokens[p.pos].text, src)
+	}
+	return e, nil
+}
+
+// EvalCondition parses src and reports whether it is truthy for vars.
+func EvalCondition(src string, vars map[string]any) (bool, error) {
+	return evalCondition(src, exprScope(vars, nil))
+}
+
+// exprScope builds the scope expressions are evaluated in.
+func exprScope(vars map[string]any, items []any) map[string]any {
+	var parent any
+	if len(items) > 1 {
+		parent = items[len(items)-2]
+	}
+	return map[string]any{"vars": vars, "item": lastItem(items), "parent": parent}
+}
+
+func evalCondition(src string, scope map[string]any) (bool, error) {
+	v, err := evalExpr(src, scope)
+	if err != nil {
+		return false, err
+	}
+	return truthy(v), nil
+}
+
+func evalExpr(src string, scope map[string]any) (any, error) {
+	e, err := parseExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	return e.eval(scope), nil
+}
+
+type tokenKind int
+
+const (
+	tokOp tokenKind = iota
+	tokString
+	tokNumber
+	tokIdent
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+type exprParser struct {
+	src    string
+	tokens []token
+	pos    int
+}
+
+func (p *exprParser) tokenize() error {
+	s := p.src
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '"' || c == '\'':
+			j := i + 1
+			for j < len(s) && s[j] != c {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(s) {
+				return fmt.Errorf("unterminated string in expression %q", s)
+			}
+			text := s[i+1 : j]
+			if c == '"' {
+				unquoted, err := strconv.Unquote(s[i : j+1])
+				if err != nil {
+					return fmt.Errorf("invalid string %s in expression %q", s[i:j+1], s)
+				}
+				text = unquoted
+			}
+			p.tokens = append(p.tokens, token{tokString, text})
+			i = j + 1
+		case c >= '0' && c <= '9' || c == '-' && i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '9':
+			j := i + 1
+			for j < len(s) && (s[j] >= '0' && s[j] <= '9' || s[j] == '.') {
+				j++
+			}
+			p.tokens = append(p.tokens, token{tokNumber, s[i:j]})
+			i = j
+		case unicode.IsLetter(rune(c)) || c == '_':
+			j := i + 1
+			for j < len(s) && (unicode.IsLetter(rune(s[j])) || unicode.IsDigit(rune(s[j])) || s[j] == '_' || s[j] == '.' || s[j] == '-') {
+				j++
+			}
+			p.tokens = append(p.tokens, token{tokIdent, s[i:j]})
+			i = j
+		default:
+			op := ""
+			for _, candidate := range []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")"} {
+				if strings.HasPrefix(s[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return fmt.Errorf("unexpected character %q in expression %q", c, s)
+			}
+			p.tokens = append(p.tokens, token{tokOp, op})
+			i += len(op)
+		}
+	}
+	return nil
+}
+
+func (p *exprParser) peekOp(ops ...string) string {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != tokOp {
+		return ""
+	}
+	for _, op := range ops {
+		if p.tokens[p.pos].text == op {
+			return op
+		}
+	}
+	return ""
+}
+
+func (p *exprParser) parseOr() (expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOp("||") != "" {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalExpr{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOp("&&") != "" {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalExpr{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (expr, error) {
+	if p.peekOp("!") != "" {
+		p.pos++
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{operand}, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *exprParser) parseCompare() (expr, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if op := p.peekOp("==", "!=", "<=", ">=", "<", ">"); op != "" {
+		p.pos++
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		return compareExpr{op: op, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *exprParser) parsePrimary() (expr, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of expression %q", p.src)
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+
+	switch tok.kind {
+	case tokString:
+		return literalExpr{tok.text}, nil
+	case tokNumber:
+		n, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q in expression %q", tok.text, p.src)
+		}
+		return literalExpr{n}, nil
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return literalExpr{true}, nil
+		case "false":
+			return literalExpr{false}, nil
+		case "null":
+			return literalExpr{nil}, nil
+		}
+		parts := strings.Split(tok.text, ".")
+		if parts[0] != "vars" && parts[0] != "item" && parts[0] != "parent" {
+			return nil, fmt.Errorf("unknown name %q in expression %q (variables are referenced as vars.<name>)", tok.text, p.src)
+		}
+		for _, part := range parts[1:] {
+			if part == "" {
+				return nil, fmt.Errorf("invalid reference %q in expression %q", tok.text, p.src)
+			}
+		}
+		return refExpr{parts}, nil
+	}
+
+	if tok.text == "(" {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peekOp(")") == "" {
+			return nil, fmt.Errorf("missing ')' in expression %q", p.src)
+		}
+		p.pos++
+		return inner, nil
+	}
+	return nil, fmt.Errorf("unexpected %q in expression %q", tok.text, p.src)
+}
+
+type literalExpr struct{ value any }
+
+func (e literalExpr) eval(map[string]any) any { return e.value }
+
+// refExpr looks a dotted path up in the scope (vars.db.host, item.name).
+type refExpr struct{ path []string }
+
+func (e refExpr) eval(scope map[string]any) any {
+	var cur any = scope
+	for _, key := range e.path {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil
+		}
+		cur = m[key]
+	}
+	return cur
+}
+
+type notExpr struct{ operand expr }
+
+func (e notExpr) eval(scope map[string]any) any { return !truthy(e.operand.eval(scope)) }
+
+type logicalExpr struct {
+	op          string
+	left, right expr
+}
+
+func (e logicalExpr) eval(scope map[string]any) any {
+	left := truthy(e.left.eval(scope))
+	if e.op == "&&" {
+		return left && truthy(e.right.eval(scope))
+	}
+	return left || truthy(e.right.eval(scope))
+}
+
+type compareExpr struct {
+	op          string
+	left, right expr
+}
+
+func (e compareExpr) eval(scope map[string]any) any {
+	l, r := normalize(e.left.eval(scope)), normalize(e.right.eval(scope))
+
+	switch e.op {
+	case "==":
+		return reflect.DeepEqual(l, r)
+	case "!=":
+		return !reflect.DeepEqual(l, r)
+	}
+
+	// ordering only makes sense between two numbers or two strings
+	if ln, ok := l.(float64); ok {
+		if rn, ok := r.(float64); ok {
+			return ordered(e.op, compareFloats(ln, rn))
+		}
+	}
+	if ls, ok := l.(string); ok {
+		if rs, ok := r.(string); ok {
+			return ordered(e.op, strings.Compare(ls, rs))
+		}
+	}
+	return false
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func ordered(op string, cmp int) bool {
+	switch op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	}
+	return cmp >= 0
+}
+
+// normalize turns every numeric type into float64 so YAML integers compare
+// equal to expression literals.
+func normalize(v any) any {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	}
+	return v
+}
+
+// truthy reports whether a value counts as true: false, null, 0, "" and empty
+// lists or maps are false, everything else is true.
+func truthy(v any) bool {
+	if v == nil {
+		return false
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return rv.Len() > 0
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return normalize(v) != 0.0
+	}
+	return true
+}
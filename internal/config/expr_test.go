@@ -0,0 +1,104 @@
+package config_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/KoHorizon/ForgeDir/internal/config"
+)
+
+func TestEvalCondition(t *testing.T) {
+	vars := map[string]any{
+		"database": "postgres",
+		"grpc":     true,
+		"frontend": false,
+		"replicas": uint64(3),
+		"entities": []any{"user"},
+		"db":       map[string]any{"host": "localhost"},
+	}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`vars.database == "postgres"`, true},
+		{`vars.database == 'mysql'`, false},
+		{`vars.database != "mysql"`, true},
+		{`vars.grpc`, true},
+		{`!vars.frontend`, true},
+		{`vars.missing`, false},
+		{`vars.missing == null`, true},
+		{`vars.replicas == 3`, true},
+		{`vars.replicas > 2 && vars.replicas <= 3`, true},
+		{`vars.frontend || vars.grpc`, true},
+		{`!(vars.grpc && vars.frontend)`, true},
+		{`vars.entities`, true},
+		{`vars.db.host == "localhost"`, true},
+		{`vars.database < "zzz"`, true},
+		{`vars.database > 1`, false},
+	}
+
+	for _, tt := range tests {
+		got, err := config.EvalCondition(tt.expr, vars)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.expr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestEvalCondition_SyntaxErrors(t *testing.T) {
+	tests := []struct {
+		expr          string
+		errorContains string
+	}{
+		{`database == "postgres"`, "unknown name"},
+		{`vars.database == "postgres`, "unterminated string"},
+		{`vars.database ==`, "unexpected end"},
+		{`(vars.grpc`, "missing ')'"},
+		{`vars.grpc vars.frontend`, "unexpected"},
+		{`vars.a = 1`, "unexpected character"},
+	}
+
+	for _, tt := range tests {
+		_, err := config.EvalCondition(tt.expr, nil)
+		if err == nil || !strings.Contains(err.Error(), tt.errorContains) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.expr, tt.errorContains, err)
+		}
+	}
+}
+
+func TestExpand_When(t *testing.T) {
+	cfg := &config.Config{
+		Variables: map[string]any{"database": "postgres"},
+		Structure: []config.StructureNode{
+			{Type: config.TypeDir, Name: "db", When: `vars.database == "postgres"`, Children: []config.StructureNode{
+				{Type: config.TypeFile, Name: "postgres.go"},
+				{Type: config.TypeFile, Name: "mysql.go", When: `vars.database == "mysql"`},
+			}},
+			{Type: config.TypeDir, Name: "proto", When: "vars.grpc"},
+		},
+	}
+
+	got, err := cfg.Expand()
+	if err != nil {
+		t.Fatalf("Expand returned unexpected error: %v", err)
+	}
+	if len(got.Structure) != 1 || got.Structure[0].Name != "db" {
+		t.Fatalf("expected only db, got %+v", got.Structure)
+	}
+	if children := got.Structure[0].Children; len(children) != 1 || children[0].Name != "postgres.go" {
+		t.Errorf("expected only postgres.go, got %+v", children)
+	}
+	if len(cfg.Structure) != 2 {
+		t.Error("Expand must not modify its receiver")
+	}
+
+	cfg.Structure[1].When = "vars.grpc &&"
+	if _, err := cfg.Expand(); err == nil || !strings.Contains(err.Error(), "invalid when at /proto") {
+		t.Errorf("expected error naming the node, got %v", err)
+	}
+}
@@ -0,0 +1,231 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/KoHorizon/ForgeDir/internal/config"
+)
+
+const specYAML = `
+projectName: svc
+language: go
+variables:
+  port: 8080
+  features: [auth, metrics]
+structure:
+  - type: dir
+    name: scripts
+    mode: "0750"
+    children:
+      - type: file
+        name: run.sh
+        content: "#!/bin/sh\n"
+  - type: file
+    name: "{{ .ProjectName }}.go"
+    when: vars.port > 0
+`
+
+const specJSON = `{
+  "projectName": "svc",
+  "language": "go",
+  "variables": {"port": 8080, "features": ["auth", "metrics"]},
+  "structure": [
+    {"type": "dir", "name": "scripts", "mode": "0750", "children": [
+      {"type": "file", "name": "run.sh", "content": "#!/bin/sh\n"}
+    ]},
+    {"type": "file", "name": "{{ .ProjectName }}.go", "when": "vars.port > 0"}
+  ]
+}`
+
+const specTOML = `
+projectName = "svc"
+language = "go"
+
+[variables]
+port = 8080
+features = ["auth", "metrics"]
+
+[[structure]]
+type = "dir"
+name = "scripts"
+mode = "0750"
+
+  [[structure.children]]
+  type = "file"
+  name = "run.sh"
+  content = "#!/bin/sh\n"
+
+[[structure]]
+type = "file"
+name = "{{ .ProjectName }}.go"
+when = "vars.port > 0"
+`
+
+func TestDecode_AllFormatsAgree(t *testing.T) {
+	want, err := config.Decode([]byte(specYAML), config.FormatYAML)
+	if err != nil {
+		t.Fatalf("decoding YAML: %v", err)
+	}
+	if want.Structure[0].Mode != "0750" || *want.Structure[0].Children[0].Content != "#!/bin/sh\n" {
+		t.Fatalf("unexpected YAML decoding: %+v", want.Structure[0])
+	}
+
+	for format, spec := range map[string]string{config.FormatJSON: specJSON, config.FormatTOML: specTOML} {
+		got, err := config.Decode([]byte(spec), format)
+		if err != nil {
+			t.Fatalf("decoding %s: %v", format, err)
+		}
+		if !reflect.DeepEqual(normalized(t, got), normalized(t, want)) {
+			t.Errorf("%s spec decoded differently:\n got %+v\nwant %+v", format, got, want)
+		}
+	}
+}
+
+func TestEncode_RoundTrip(t *testing.T) {
+	want, err := config.Decode([]byte(specYAML), config.FormatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, format := range config.Formats {
+		out, err := config.Encode(want, format)
+		if err != nil {
+			t.Fatalf("encoding %s: %v", format, err)
+		}
+		got, err := config.Decode(out, format)
+		if err != nil {
+			t.Fatalf("decoding encoded %s: %v\n%s", format, err, out)
+		}
+		if !reflect.DeepEqual(normalized(t, got), normalized(t, want)) {
+			t.Errorf("%s round trip changed the spec:\n%s", format, out)
+		}
+	}
+}
+
+// normalized re-encodes a config as YAML so numeric types of variables compare equal
+func normalized(t *testing.T, cfg *config.Config) string {
+	t.Helper()
+	out, err := config.Encode(cfg, config.FormatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestDecode_Errors(t *testing.T) {
+	tests := []struct {
+		format        string
+		spec          string
+		errorContains string
+	}{
+		{format: config.FormatJSON, spec: `{"projectName": "svc",}`, errorContains: "invalid JSON"},
+		{format: config.FormatTOML, spec: `projectName = `, errorContains: "invalid TOML"},
+		{format: "xml", spec: `<spec/>`, errorContains: "unknown spec format"},
+	}
+	for _, tt := range tests {
+		_, err := config.Decode([]byte(tt.spec), tt.format)
+		if err == nil || !strings.Contains(err.Error(), tt.errorContains) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.format, tt.errorContains, err)
+		}
+	}
+}
+
+func TestLoad_FormatByExtensionAcrossIncludes(t *testing.T) {
+	dir := writeSpecs(t, map[string]string{
+		"spec.json": `{"projectName": "svc", "language": "go",
+  "include": {"path": "ci.toml", "at": ".github"},
+  "extends": "base.yaml"}`,
+		"ci.toml": `
+[[structure]]
+type = "file"
+name = "ci.yml"
+`,
+		"base.yaml": `
+structure:
+  - type: file
+    name: go.mod
+`,
+	})
+
+	cfg, err := config.Load(filepath.Join(dir, "spec.json"))
+	if err != nil {
+		t.Fatalf("Load returned unexpected error: %v", err)
+	}
+	var names []string
+	for _, n := range cfg.Structure {
+		names = append(names, n.Name)
+	}
+	if got := strings.Join(names, " "); got != "go.mod .github" {
+		t.Errorf("unexpected structure: %s", got)
+	}
+}
+
+func TestLoadFormat_Stdin(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = old }()
+
+	go func() {
+		w.Write([]byte(specJSON))
+		w.Close()
+	}()
+
+	cfg, err := config.LoadFormat(config.Stdin, config.FormatJSON)
+	if err != nil {
+		t.Fatalf("LoadFormat returned unexpected error: %v", err)
+	}
+	if cfg.ProjectName != "svc" || len(cfg.Structure) != 2 {
+		t.Errorf("unexpected config from stdin: %+v", cfg)
+	}
+}
+
+func TestLoad_RecordsNodePositions(t *testing.T) {
+	dir := writeSpecs(t, map[string]string{
+		"spec.yaml": `projectName: p
+structure:
+  - type: dir
+    name: src
+    children:
+      - {type: file, name: main.go}
+projects:
+  - projectName: api
+    structure:
+      - type: file
+        name: api.go
+`,
+		"spec.json": "{\"projectName\": \"p\", \"structure\": [\n  {\"type\": \"file\", \"name\": \"a\"}]}",
+	})
+
+	cfg, err := config.Load(filepath.Join(dir, "spec.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "spec.yaml")
+	for _, tt := range []struct {
+		node config.StructureNode
+		want config.Position
+	}{
+		{cfg.Structure[0], config.Position{File: file, Line: 3, Column: 5}},
+		{cfg.Structure[0].Children[0], config.Position{File: file, Line: 6, Column: 10}},
+		{cfg.Projects[0].Structure[0], config.Position{File: file, Line: 10, Column: 9}},
+	} {
+		if tt.node.Pos != tt.want {
+			t.Errorf("%s: got position %+v, want %+v", tt.node.Name, tt.node.Pos, tt.want)
+		}
+	}
+
+	cfg, err = config.Load(filepath.Join(dir, "spec.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pos := cfg.Structure[0].Pos; pos.Line != 2 || pos.Column != 4 {
+		t.Errorf("unexpected JSON position %+v", pos)
+	}
+}
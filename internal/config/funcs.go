@@ -0,0 +1,31 @@
+package config
+
+import (
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// TemplateFuncs returns the helper functions available to every template:
+// the generators' file templates and the templated node names of a spec.
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"className": className,
+	}
+}
+
+// className turns a file name such as "user_service" or "order-item" into a
+// PascalCase class name ("UserService", "OrderItem").
+func className(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var b strings.Builder
+	for _, w := range words {
+		runes := []rune(w)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	return b.String()
+}
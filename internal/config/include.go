@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/KoHorizon/ForgeDir/internal/utils"
+)
+
+// Include pulls the structure of another spec fragment into this spec,
+// mounted under the At directory (the project root when empty).
+// In YAML it is either a plain path or a {path, at} mapping.
+type Include struct {
+	Path string `yaml:"path"`
+	At   string `yaml:"at,omitempty"`
+}
+
+// UnmarshalYAML accepts both `include: common/ci.yaml` and
+// `include: {path: common/ci.yaml, at: .github}`.
+func (i *Include) UnmarshalYAML(unmarshal func(any) error) error {
+	var p string
+	if err := unmarshal(&p); err == nil {
+		*i = Include{Path: p}
+		return nil
+	}
+
+	type plain Include
+	var v plain
+	if err := unmarshal(&v); err != nil {
+		return err
+	}
+	*i = Include(v)
+	return nil
+}
+
+// Includes is a list of Include entries. A single entry without the list is accepted too.
+type Includes []Include
+
+// UnmarshalYAML accepts a single include as well as a list of them.
+func (l *Includes) UnmarshalYAML(unmarshal func(any) error) error {
+	var list []Include
+	if err := unmarshal(&list); err == nil {
+		*l = list
+		return nil
+	}
+
+	var single Include
+	if err := unmarshal(&single); err != nil {
+		return err
+	}
+	*l = Includes{single}
+	return nil
+}
+
+// mountPoint splits an `at` directory into path components valid under profile.
+func mountPoint(at string, profile *utils.Portability) ([]string, error) {
+	at = strings.ReplaceAll(at, "\\", "/")
+	if path.IsAbs(at) {
+		return nil, fmt.Errorf("absolute mount points are not allowed: %s", at)
+	}
+	at = strings.TrimRight(at, "/")
+	if at == "" || at == "." {
+		return nil, nil
+	}
+
+	parts := strings.Split(at, "/")
+	for _, part := range parts {
+		if err := profile.ValidateName(part); err != nil {
+			return nil, fmt.Errorf("invalid mount point %q: %w", at, err)
+		}
+	}
+	return parts, nil
+}
+
+// mount merges nodes into the directory at (creating it as needed) inside structure.
+func mount(structure []StructureNode, at []string, nodes []StructureNode) []StructureNode {
+	for i := len(at) - 1; i >= 0; i-- {
+		nodes = []StructureNode{{Type: TypeDir, Name: at[i], Children: nodes}}
+	}
+	return mergeNodes(structure, nodes)
+}
+
+// mergeNodes adds src to dst. A directory in src whose name matches a
+// directory in dst is merged into it; anything else is appended.
+func mergeNodes(dst, src []StructureNode) []StructureNode {
+	out := append([]StructureNode(nil), dst...)
+	for _, n := range src {
+		merged := false
+		if n.Type == TypeDir {
+			for i := range out {
+				if out[i].Type == TypeDir && out[i].Name == n.Name {
+					out[i].Children = mergeNodes(out[i].Children, n.Children)
+					merged = true
+					break
+				}
+			}
+		}
+		if !merged {
+			out = append(out, n)
+		}
+	}
+	return out
+}
@@ -0,0 +1,222 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KoHorizon/ForgeDir/internal/config"
+)
+
+// writeSpecs writes name -> content files under a temp dir and returns the dir
+func writeSpecs(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("unable to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("unable to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestLoad_IncludeMountedAt(t *testing.T) {
+	dir := writeSpecs(t, map[string]string{
+		"spec.yaml": `
+projectName: svc
+language: go
+include:
+  - path: common/ci.yaml
+    at: .github
+  - common/docs.yaml
+structure:
+  - type: dir
+    name: docs
+    children:
+      - type: file
+        name: index.md
+`,
+		"common/ci.yaml": `
+structure:
+  - type: dir
+    name: workflows
+    children:
+      - type: file
+        name: ci.yml
+`,
+		"common/docs.yaml": `
+structure:
+  - type: dir
+    name: docs
+    children:
+      - type: file
+        name: CONTRIBUTING.md
+`,
+	})
+
+	cfg, err := config.Load(filepath.Join(dir, "spec.yaml"))
+	if err != nil {
+		t.Fatalf("Load returned unexpected error: %v", err)
+	}
+
+	if len(cfg.Structure) != 2 {
+		t.Fatalf("expected docs and .github at the root, got %+v", cfg.Structure)
+	}
+	docs := cfg.Structure[0]
+	if docs.Name != "docs" || len(docs.Children) != 2 || docs.Children[1].Name != "CONTRIBUTING.md" {
+		t.Errorf("expected docs fragment merged into the existing docs dir, got %+v", docs)
+	}
+	github := cfg.Structure[1]
+	if github.Name != ".github" || github.Children[0].Name != "workflows" || github.Children[0].Children[0].Name != "ci.yml" {
+		t.Errorf("expected ci fragment mounted at .github, got %+v", github)
+	}
+}
+
+func TestLoad_IncludeSingleMapping(t *testing.T) {
+	dir := writeSpecs(t, map[string]string{
+		"spec.yaml": `
+projectName: svc
+language: go
+include: {path: ci.yaml, at: .github/workflows}
+`,
+		"ci.yaml": `
+structure:
+  - type: file
+    name: ci.yml
+`,
+	})
+
+	cfg, err := config.Load(filepath.Join(dir, "spec.yaml"))
+	if err != nil {
+		t.Fatalf("Load returned unexpected error: %v", err)
+	}
+	if len(cfg.Structure) != 1 || cfg.Structure[0].Children[0].Children[0].Name != "ci.yml" {
+		t.Errorf("expected ci.yml under .github/workflows, got %+v", cfg.Structure)
+	}
+}
+
+func TestLoad_IncludeErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		files         map[string]string
+		errorContains string
+	}{
+		{
+			name: "cycle",
+			files: map[string]string{
+				"spec.yaml": "include: a.yaml\n",
+				"a.yaml":    "include: b.yaml\n",
+				"b.yaml":    "include: a.yaml\n",
+			},
+			errorContains: "include cycle",
+		},
+		{
+			name: "escape spec directory",
+			files: map[string]string{
+				"spec.yaml": "include: ../outside.yaml\n",
+			},
+			errorContains: "path outside project root not allowed",
+		},
+		{
+			name: "unsafe mount point",
+			files: map[string]string{
+				"spec.yaml": "include: {path: a.yaml, at: ../up}\n",
+				"a.yaml":    "structure: []\n",
+			},
+			errorContains: "invalid mount point",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeSpecs(t, tt.files)
+			_, err := config.Load(filepath.Join(dir, "spec.yaml"))
+			if err == nil || !strings.Contains(err.Error(), tt.errorContains) {
+				t.Errorf("expected error containing %q, got %v", tt.errorContains, err)
+			}
+		})
+	}
+}
+
+func TestLoad_MountPointFollowsPortability(t *testing.T) {
+	files := map[string]string{
+		"spec.yaml": "include: {path: a.yaml, at: \"docs?\"}\n",
+		"a.yaml":    "structure: [{type: file, name: index.md}]\n",
+	}
+	dir := writeSpecs(t, files)
+	if _, err := config.Load(filepath.Join(dir, "spec.yaml")); err == nil || !strings.Contains(err.Error(), "invalid character '?'") {
+		t.Errorf("portable: expected an invalid character error, got %v", err)
+	}
+
+	cfg, err := config.LoadWith(filepath.Join(dir, "spec.yaml"), config.LoadOptions{Portability: "posix"})
+	if err != nil {
+		t.Fatalf("--portability posix: unexpected error: %v", err)
+	}
+	if cfg.Portability != "posix" || cfg.Structure[0].Name != "docs?" {
+		t.Errorf("--portability posix: unexpected spec %+v", cfg)
+	}
+
+	files["spec.yaml"] = "portability: posix\nprojects:\n  - projectName: api\n    include: {path: a.yaml, at: \"docs?\"}\n"
+	dir = writeSpecs(t, files)
+	if _, err := config.Load(filepath.Join(dir, "spec.yaml")); err != nil {
+		t.Errorf("projects inherit the spec's profile, got %v", err)
+	}
+}
+
+func TestLoad_ContentFromIsRelativeToDeclaringSpec(t *testing.T) {
+	dir := writeSpecs(t, map[string]string{
+		"spec.yaml": `
+include: {path: common/ci.yaml, at: .github}
+structure:
+  - type: file
+    name: .editorconfig
+    contentFrom: files/editorconfig
+`,
+		"common/ci.yaml": `
+structure:
+  - type: file
+    name: ci.yml
+    contentFrom: ci.yml
+  - type: copy
+    name: logo.png
+    from: assets/logo.png
+`,
+	})
+
+	cfg, err := config.Load(filepath.Join(dir, "spec.yaml"))
+	if err != nil {
+		t.Fatalf("Load returned unexpected error: %v", err)
+	}
+	if got := cfg.Structure[0].ContentFrom; got != filepath.Join(dir, "files", "editorconfig") {
+		t.Errorf("unexpected contentFrom for .editorconfig: %s", got)
+	}
+	if got := cfg.Structure[1].Children[0].ContentFrom; got != filepath.Join(dir, "common", "ci.yml") {
+		t.Errorf("unexpected contentFrom for ci.yml: %s", got)
+	}
+	if got := cfg.Structure[1].Children[1].From; got != filepath.Join(dir, "common", "assets", "logo.png") {
+		t.Errorf("unexpected from for logo.png: %s", got)
+	}
+}
+
+func TestLoad_ContentFromMustStayInsideSpecDir(t *testing.T) {
+	for _, key := range []string{"contentFrom", "from"} {
+		dir := writeSpecs(t, map[string]string{
+			"spec.yaml": `
+structure:
+  - type: file
+    name: passwd
+    ` + key + `: ../../../etc/passwd
+`,
+		})
+
+		_, err := config.Load(filepath.Join(dir, "spec.yaml"))
+		if err == nil || !strings.Contains(err.Error(), "path outside project root not allowed") {
+			t.Errorf("%s: expected containment error, got %v", key, err)
+		}
+	}
+}
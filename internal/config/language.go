@@ -0,0 +1,25 @@
+package config
+
+// Languages returns every language used by the spec: cfg.Language first (even
+// when empty, since it applies to every node without an override), then the
+// node-level overrides in order of first appearance.
+func (c *Config) Languages() []string {
+	seen := map[string]bool{c.Language: true}
+	langs := []string{c.Language}
+	add := func(lang string) {
+		if lang != "" && !seen[lang] {
+			seen[lang] = true
+			langs = append(langs, lang)
+		}
+	}
+
+	var walk func(nodes []StructureNode)
+	walk = func(nodes []StructureNode) {
+		for _, n := range nodes {
+			add(n.Language)
+			walk(n.Children)
+		}
+	}
+	walk(c.Structure)
+	return langs
+}
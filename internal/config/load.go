@@ -1,23 +1,190 @@
 package config
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/BurntSushi/toml"
 	"github.com/goccy/go-yaml"
 )
 
-func LoadConfigFromYaml(filename string) (*Config, error) {
-	var cfg Config
+// Spec file formats.
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+	FormatTOML = "toml"
+)
+
+// Formats lists the supported spec formats.
+var Formats = []string{FormatYAML, FormatJSON, FormatTOML}
+
+// Stdin is the file name that makes Load read the spec from standard input.
+const Stdin = "-"
+
+// FormatOf picks the spec format from a file extension; anything that isn't
+// .json or .toml is read as YAML.
+func FormatOf(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		return FormatJSON
+	case ".toml":
+		return FormatTOML
+	}
+	return FormatYAML
+}
+
+// checkFormat rejects unknown format names.
+func checkFormat(format string) error {
+	for _, f := range Formats {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown spec format %q (available: %s)", format, strings.Join(Formats, ", "))
+}
 
+func LoadConfigFromYaml(filename string) (*Config, error) {
 	content, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, err
 	}
+	return Decode(content, FormatYAML)
+}
+
+// LoadConfigFile reads a spec file, or standard input for Stdin, and decodes
+// it in format, or in the format of its extension when format is empty.
+// Unlike Load it leaves includes and extends unresolved.
+func LoadConfigFile(filename, format string) (*Config, error) {
+	if format == "" {
+		format = FormatOf(filename)
+	}
 
-	err = yaml.Unmarshal(content, &cfg)
+	var content []byte
+	var err error
+	if filename == Stdin {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(filename)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return &cfg, err
+	name := filename
+	if filename == Stdin {
+		name = "<stdin>"
+	}
+	cfg, err := Decode(content, format)
+	if err != nil {
+		return nil, setFile(err, name)
+	}
+	setPositionFile(cfg, name)
+	return cfg, nil
+}
+
+// Decode parses a spec in the given format. Decoding is strict: every
+// unknown key is reported, as a SpecErrors list with positions and
+// suggestions. JSON and TOML are converted to YAML first, so every format
+// goes through the same decoding rules. Structure nodes of YAML and JSON
+// specs record the position they are declared at.
+func Decode(content []byte, format string) (*Config, error) {
+	if err := checkFormat(format); err != nil {
+		return nil, err
+	}
+
+	src := content
+	switch format {
+	case FormatJSON:
+		// JSON is valid YAML, but report JSON syntax errors as such
+		var v any
+		if err := json.Unmarshal(content, &v); err != nil {
+			return nil, jsonError(content, err)
+		}
+	case FormatTOML:
+		var v map[string]any
+		if err := toml.Unmarshal(content, &v); err != nil {
+			var perr toml.ParseError
+			if errors.As(err, &perr) {
+				return nil, SpecErrors{newSpecError(src, perr.Position.Line, perr.Position.Col, "invalid TOML: "+perr.Message)}
+			}
+			return nil, fmt.Errorf("invalid TOML: %w", err)
+		}
+		converted, err := yaml.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		content = converted
+	}
+
+	var cfg Config
+	err := checkKeys(content)
+	if err == nil {
+		err = yamlError(content, yaml.Unmarshal(content, &cfg))
+	}
+	if err != nil {
+		if format == FormatTOML {
+			return nil, tomlPositions(src, err)
+		}
+		return nil, err
+	}
+	if format != FormatTOML {
+		recordPositions(content, &cfg)
+	}
+	return &cfg, nil
+}
+
+// jsonError reports a JSON syntax error at its line and column.
+func jsonError(src []byte, err error) error {
+	var serr *json.SyntaxError
+	if !errors.As(err, &serr) {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	before := src[:serr.Offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+	return SpecErrors{newSpecError(src, line, column, "invalid JSON: "+serr.Error())}
+}
+
+// Encode writes cfg in the given format. Key order follows the Config fields
+// in YAML and JSON; TOML sorts keys.
+func Encode(cfg *Config, format string) ([]byte, error) {
+	if err := checkFormat(format); err != nil {
+		return nil, err
+	}
+
+	out, err := yaml.MarshalWithOptions(cfg, yaml.IndentSequence(true))
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case FormatJSON:
+		raw, err := yaml.YAMLToJSON(out)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, raw, "", "  "); err != nil {
+			return nil, err
+		}
+		buf.WriteByte('\n')
+		return buf.Bytes(), nil
+	case FormatTOML:
+		var v map[string]any
+		if err := yaml.Unmarshal(out, &v); err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(v); err != nil {
+			return nil, fmt.Errorf("encoding TOML: %w", err)
+		}
+		return bytes.TrimLeft(buf.Bytes(), "\n"), nil
+	}
+	return out, nil
 }
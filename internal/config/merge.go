@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Merge resolves child on top of base and returns the combined Config:
+//   - projectName, language and output come from the child when it sets them;
+//   - variables are the base's, overridden key by key by the child's;
+//   - the paths listed in the child's `remove` are deleted from the base tree,
+//     then the child's nodes are merged in (directories merge by name, other
+//     nodes replace their namesake);
+//   - the child's projects replace the base's when it declares any.
+//
+// Neither input is modified.
+func Merge(base, child *Config) (*Config, error) {
+	out := *base
+	out.Extends = ""
+	out.Remove = nil
+
+	if child.ProjectName != "" {
+		out.ProjectName = child.ProjectName
+	}
+	if child.Language != "" {
+		out.Language = child.Language
+	}
+	if child.Output != "" {
+		out.Output = child.Output
+	}
+	if child.Portability != "" {
+		out.Portability = child.Portability
+	}
+	out.Variables = mergeVariables(base.Variables, child.Variables)
+	if len(child.Projects) > 0 {
+		out.Projects = child.Projects
+	}
+
+	structure := base.Structure
+	for _, p := range child.Remove {
+		parts := strings.Split(strings.Trim(strings.ReplaceAll(p, "\\", "/"), "/"), "/")
+		var removed bool
+		structure, removed = removeNode(structure, parts)
+		if !removed {
+			return nil, fmt.Errorf("remove %q: no such node in the base spec", p)
+		}
+	}
+	out.Structure = overlayNodes(structure, child.Structure)
+	return &out, nil
+}
+
+// overlayNodes lays the child's nodes over the base's, for extends and for
+// `paths:` lists. Unlike mergeNodes, used for includes, a child node replaces
+// its base namesake instead of being added next to it: a directory in child whose name matches a directory in base is
+// overlaid onto it (its own language, if any, wins); any other node replaces
+// the base node of the same name and type, or is appended.
+func overlayNodes(base, child []StructureNode) []StructureNode {
+	out := append([]StructureNode(nil), base...)
+	for _, n := range child {
+		i := indexOfNode(out, n.Type, n.Name)
+		switch {
+		case i < 0:
+			out = append(out, n)
+		case n.Type == TypeDir:
+			children := overlayNodes(out[i].Children, n.Children)
+			if n.Language == "" {
+				n.Language = out[i].Language
+			}
+			out[i] = n
+			out[i].Children = children
+		default:
+			out[i] = n
+		}
+	}
+	return out
+}
+
+// indexOfNode returns the index of the node with the given type and name, or -1.
+func indexOfNode(nodes []StructureNode, typ, name string) int {
+	for i, n := range nodes {
+		if n.Type == typ && n.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// removeNode returns a copy of nodes without the node at the given path.
+func removeNode(nodes []StructureNode, parts []string) ([]StructureNode, bool) {
+	for i, n := range nodes {
+		if n.Name != parts[0] {
+			continue
+		}
+
+		out := append([]StructureNode(nil), nodes...)
+		if len(parts) == 1 {
+			return append(out[:i], out[i+1:]...), true
+		}
+		children, removed := removeNode(n.Children, parts[1:])
+		if !removed {
+			return nodes, false
+		}
+		out[i].Children = children
+		return out, true
+	}
+	return nodes, false
+}
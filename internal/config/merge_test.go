@@ -0,0 +1,171 @@
+package config_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KoHorizon/ForgeDir/internal/config"
+)
+
+func TestMerge(t *testing.T) {
+	base := &config.Config{
+		ProjectName: "base",
+		Language:    "go",
+		Variables:   map[string]any{"db": "postgres", "port": 8080},
+		Structure: []config.StructureNode{
+			{Type: config.TypeDir, Name: "pkg", Children: []config.StructureNode{
+				{Type: config.TypeDir, Name: "api"},
+				{Type: config.TypeFile, Name: "util.go"},
+			}},
+			{Type: config.TypeFile, Name: "README.md"},
+		},
+	}
+	child := &config.Config{
+		ProjectName: "orders",
+		Remove:      []string{"pkg/api", "README.md"},
+		Variables:   map[string]any{"db": "mysql"},
+		Structure: []config.StructureNode{
+			{Type: config.TypeDir, Name: "pkg", Children: []config.StructureNode{
+				{Type: config.TypeFile, Name: "orders.go"},
+			}},
+		},
+	}
+
+	got, err := config.Merge(base, child)
+	if err != nil {
+		t.Fatalf("Merge returned unexpected error: %v", err)
+	}
+
+	if got.ProjectName != "orders" || got.Language != "go" {
+		t.Errorf("expected child name and base language, got %q / %q", got.ProjectName, got.Language)
+	}
+	if got.Variables["db"] != "mysql" || got.Variables["port"] != 8080 {
+		t.Errorf("expected overridden variables, got %v", got.Variables)
+	}
+	if len(got.Structure) != 1 {
+		t.Fatalf("expected README.md removed, got %+v", got.Structure)
+	}
+	pkg := got.Structure[0].Children
+	if len(pkg) != 2 || pkg[0].Name != "util.go" || pkg[1].Name != "orders.go" {
+		t.Errorf("expected pkg/api removed and orders.go added, got %+v", pkg)
+	}
+	if len(base.Structure) != 2 || len(base.Structure[0].Children) != 2 {
+		t.Errorf("base must not be modified, got %+v", base.Structure)
+	}
+}
+
+func TestMerge_RemoveUnknownPath(t *testing.T) {
+	base := &config.Config{Structure: []config.StructureNode{{Type: config.TypeDir, Name: "pkg"}}}
+	child := &config.Config{Remove: []string{"pkg/missing"}}
+
+	if _, err := config.Merge(base, child); err == nil || !strings.Contains(err.Error(), "no such node") {
+		t.Errorf("expected unknown path error, got %v", err)
+	}
+}
+
+func TestLoad_ExtendsChain(t *testing.T) {
+	dir := writeSpecs(t, map[string]string{
+		"base.yaml": `
+projectName: base
+language: go
+structure:
+  - type: file
+    name: main.go
+`,
+		"service.yaml": `
+extends: base.yaml
+structure:
+  - type: file
+    name: service.go
+`,
+		"orders/spec.yaml": `
+extends: ../service.yaml
+projectName: orders
+remove: [main.go]
+`,
+	})
+
+	cfg, err := config.Load(filepath.Join(dir, "orders", "spec.yaml"))
+	if err != nil {
+		t.Fatalf("Load returned unexpected error: %v", err)
+	}
+	if cfg.ProjectName != "orders" || cfg.Language != "go" || cfg.Extends != "" {
+		t.Errorf("unexpected resolved config: %+v", cfg)
+	}
+	if len(cfg.Structure) != 1 || cfg.Structure[0].Name != "service.go" {
+		t.Errorf("expected only service.go, got %+v", cfg.Structure)
+	}
+}
+
+func TestLoad_BaseSpecIncludes(t *testing.T) {
+	dir := writeSpecs(t, map[string]string{
+		"base/base.yaml": `
+language: go
+include: [ci.yaml]
+`,
+		"base/ci.yaml": `
+structure:
+  - type: file
+    name: Makefile
+`,
+		"app/fgdir.yaml": `
+extends: ../base/base.yaml
+projectName: app
+`,
+	})
+
+	cfg, err := config.Load(filepath.Join(dir, "app", "fgdir.yaml"))
+	if err != nil {
+		t.Fatalf("Load returned unexpected error: %v", err)
+	}
+	if len(cfg.Structure) != 1 || cfg.Structure[0].Name != "Makefile" {
+		t.Errorf("expected the base's included Makefile, got %+v", cfg.Structure)
+	}
+}
+
+func TestLoad_ExtendsErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		spec          string // the spec to load, spec.yaml when empty
+		files         map[string]string
+		errorContains string
+	}{
+		{
+			name:          "remove without extends",
+			files:         map[string]string{"spec.yaml": "language: go\nremove: [main.go]\n"},
+			errorContains: "remove needs extends",
+		},
+		{
+			name: "cycle",
+			files: map[string]string{
+				"spec.yaml": "extends: a.yaml\n",
+				"a.yaml":    "extends: spec.yaml\n",
+			},
+			errorContains: "extends cycle",
+		},
+		{
+			name: "base include escaping the base directory",
+			spec: "app/spec.yaml",
+			files: map[string]string{
+				"app/spec.yaml":  "extends: ../base/base.yaml\n",
+				"base/base.yaml": "include: ../app/spec.yaml\n",
+			},
+			errorContains: "path outside project root not allowed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeSpecs(t, tt.files)
+			spec := tt.spec
+			if spec == "" {
+				spec = "spec.yaml"
+			}
+			_, err := config.Load(filepath.Join(dir, filepath.FromSlash(spec)))
+			if err == nil || !strings.Contains(err.Error(), tt.errorContains) {
+				t.Errorf("expected error containing %q, got %v", tt.errorContains, err)
+			}
+		})
+	}
+}
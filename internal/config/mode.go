@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Mode is a permission given in the spec as an octal string: "0755", "755" or "0o755".
+type Mode string
+
+// UnmarshalYAML keeps the literal text of the value, so an unquoted 0755 is
+// not read as the integer 493.
+func (m *Mode) UnmarshalYAML(b []byte) error {
+	s := strings.TrimSpace(string(b))
+	if s == "null" || s == "~" {
+		*m = ""
+		return nil
+	}
+	*m = Mode(strings.Trim(s, `"'`))
+	return nil
+}
+
+// Perm returns the permission bits, or def when no mode is set.
+func (m Mode) Perm(def os.FileMode) (os.FileMode, error) {
+	if m == "" {
+		return def, nil
+	}
+	s := strings.TrimPrefix(strings.TrimPrefix(string(m), "0o"), "0O")
+	n, err := strconv.ParseUint(s, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid mode %q: must be an octal number such as \"0755\"", string(m))
+	}
+	if n > 0777 {
+		return 0, fmt.Errorf("invalid mode %q: only permission bits (up to 0777) are allowed", string(m))
+	}
+	return os.FileMode(n), nil
+}
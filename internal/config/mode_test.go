@@ -0,0 +1,61 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/KoHorizon/ForgeDir/internal/config"
+)
+
+func TestMode_FromYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    string
+		want    os.FileMode
+		wantErr bool
+	}{
+		{name: "unquoted", mode: "0755", want: 0755},
+		{name: "quoted", mode: `"0700"`, want: 0700},
+		{name: "without leading zero", mode: "640", want: 0640},
+		{name: "0o prefix", mode: "0o750", want: 0750},
+		{name: "not octal", mode: "0789", wantErr: true},
+		{name: "special bits", mode: "4755", wantErr: true},
+		{name: "symbolic", mode: "u+x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "spec.yaml")
+			spec := "projectName: p\nlanguage: go\nstructure:\n  - type: file\n    name: run.sh\n    mode: " + tt.mode + "\n"
+			if err := os.WriteFile(path, []byte(spec), 0644); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := config.LoadConfigFromYaml(path)
+			if err != nil {
+				t.Fatalf("unexpected load error: %v", err)
+			}
+
+			got, err := cfg.Structure[0].Mode.Perm(0644)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error for mode %s, got %o", tt.mode, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %o, got %o", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMode_DefaultWhenUnset(t *testing.T) {
+	var m config.Mode
+	if got, err := m.Perm(0644); err != nil || got != 0644 {
+		t.Errorf("expected default 0644, got %o (%v)", got, err)
+	}
+}
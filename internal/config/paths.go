@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// PathNodes turns a compact `paths:` list into a structure tree. Each entry is
+// a slash-separated path relative to the project root; a trailing slash makes
+// it a directory, otherwise its last component is a file. Parent directories
+// are created as needed and shared between entries.
+func PathNodes(paths []string) ([]StructureNode, error) {
+	var nodes []StructureNode
+	for _, p := range paths {
+		parts, isDir, err := splitSpecPath(p)
+		if err != nil {
+			return nil, err
+		}
+
+		leaf := StructureNode{Type: TypeFile, Name: parts[len(parts)-1]}
+		if isDir {
+			leaf.Type = TypeDir
+		}
+		nodes = mount(nodes, parts[:len(parts)-1], []StructureNode{leaf})
+	}
+	return nodes, nil
+}
+
+// splitSpecPath splits a `paths:` entry into its components. Names themselves
+// are checked later, like any other node name.
+func splitSpecPath(p string) ([]string, bool, error) {
+	p = strings.ReplaceAll(strings.TrimSpace(p), "\\", "/")
+	if p == "" {
+		return nil, false, fmt.Errorf("empty entry in paths")
+	}
+	if path.IsAbs(p) || len(p) >= 2 && p[1] == ':' {
+		return nil, false, fmt.Errorf("invalid path %q: absolute paths are not allowed", p)
+	}
+
+	isDir := strings.HasSuffix(p, "/")
+	parts := strings.Split(strings.TrimSuffix(p, "/"), "/")
+	for _, part := range parts {
+		if part == "" || part == "." {
+			return nil, false, fmt.Errorf("invalid path %q: empty path component", p)
+		}
+	}
+	return parts, isDir, nil
+}
+
+// mergePaths adds the nodes of a `paths:` list to structure. Nodes spelled
+// out in structure win over the bare ones from paths, so a file can be listed
+// in paths and given content or a mode in structure.
+func mergePaths(structure []StructureNode, paths []string) ([]StructureNode, error) {
+	if len(paths) == 0 {
+		return structure, nil
+	}
+	nodes, err := PathNodes(paths)
+	if err != nil {
+		return nil, err
+	}
+	return overlayNodes(nodes, structure), nil
+}
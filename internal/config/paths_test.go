@@ -0,0 +1,105 @@
+package config_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/KoHorizon/ForgeDir/internal/config"
+)
+
+func TestPathNodes(t *testing.T) {
+	nodes, err := config.PathNodes([]string{
+		"cmd/server/main.go",
+		"internal/handlers/",
+		"internal/handlers/user.go",
+		"go.mod",
+		`docs\guide.md`,
+	})
+	if err != nil {
+		t.Fatalf("PathNodes returned unexpected error: %v", err)
+	}
+
+	want := []config.StructureNode{
+		{Type: config.TypeDir, Name: "cmd", Children: []config.StructureNode{
+			{Type: config.TypeDir, Name: "server", Children: []config.StructureNode{
+				{Type: config.TypeFile, Name: "main.go"},
+			}},
+		}},
+		{Type: config.TypeDir, Name: "internal", Children: []config.StructureNode{
+			{Type: config.TypeDir, Name: "handlers", Children: []config.StructureNode{
+				{Type: config.TypeFile, Name: "user.go"},
+			}},
+		}},
+		{Type: config.TypeFile, Name: "go.mod"},
+		{Type: config.TypeDir, Name: "docs", Children: []config.StructureNode{
+			{Type: config.TypeFile, Name: "guide.md"},
+		}},
+	}
+	if !reflect.DeepEqual(nodes, want) {
+		t.Errorf("unexpected tree:\n got %+v\nwant %+v", nodes, want)
+	}
+}
+
+func TestPathNodes_Invalid(t *testing.T) {
+	tests := []struct {
+		path          string
+		errorContains string
+	}{
+		{path: "", errorContains: "empty entry"},
+		{path: "/etc/passwd", errorContains: "absolute paths are not allowed"},
+		{path: `C:\Windows\evil.exe`, errorContains: "absolute paths are not allowed"},
+		{path: "cmd//main.go", errorContains: "empty path component"},
+		{path: "./main.go", errorContains: "empty path component"},
+	}
+	for _, tt := range tests {
+		_, err := config.PathNodes([]string{tt.path})
+		if err == nil || !strings.Contains(err.Error(), tt.errorContains) {
+			t.Errorf("%q: expected error containing %q, got %v", tt.path, tt.errorContains, err)
+		}
+	}
+}
+
+func TestLoad_PathsMergedWithStructure(t *testing.T) {
+	dir := writeSpecs(t, map[string]string{
+		"spec.yaml": `
+projectName: svc
+language: go
+paths:
+  - cmd/server/main.go
+  - internal/handlers/
+  - scripts/build.sh
+structure:
+  - type: dir
+    name: scripts
+    children:
+      - type: file
+        name: build.sh
+        mode: "0755"
+  - type: file
+    name: go.mod
+`,
+	})
+
+	cfg, err := config.Load(filepath.Join(dir, "spec.yaml"))
+	if err != nil {
+		t.Fatalf("Load returned unexpected error: %v", err)
+	}
+	if cfg.Paths != nil {
+		t.Errorf("expected paths to be merged away, got %v", cfg.Paths)
+	}
+
+	var names []string
+	for _, n := range cfg.Structure {
+		names = append(names, n.Type+":"+n.Name)
+	}
+	if got := strings.Join(names, " "); got != "dir:cmd dir:internal dir:scripts file:go.mod" {
+		t.Errorf("unexpected top-level nodes: %s", got)
+	}
+
+	scripts := cfg.Structure[2].Children
+	if len(scripts) != 1 || scripts[0].Mode != "0755" {
+		t.Errorf("expected the structure entry to win for scripts/build.sh, got %+v", scripts)
+	}
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+)
+
+// Position is where a structure node is declared. Line is 0 when it is not
+// known: for TOML specs and for nodes built from a paths list.
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// recordPositions sets the position of every structure node of cfg from the
+// YAML (or JSON) source it was decoded from.
+func recordPositions(src []byte, cfg *Config) {
+	file, err := parser.ParseBytes(src, 0)
+	if err != nil || len(file.Docs) == 0 {
+		return
+	}
+	configPositions(file.Docs[0].Body, cfg)
+}
+
+func configPositions(node ast.Node, cfg *Config) {
+	for _, mv := range mappingValues(unwrap(node)) {
+		switch mv.Key.GetToken().Value {
+		case "structure":
+			nodePositions(mv.Value, cfg.Structure)
+		case "projects":
+			for i, v := range sequenceValues(mv.Value) {
+				if i < len(cfg.Projects) {
+					configPositions(v, &cfg.Projects[i])
+				}
+			}
+		}
+	}
+}
+
+func nodePositions(seq ast.Node, nodes []StructureNode) {
+	for i, v := range sequenceValues(seq) {
+		if i >= len(nodes) {
+			return
+		}
+		v = unwrap(v)
+		if tk := v.GetToken(); tk != nil {
+			nodes[i].Pos = Position{Line: tk.Position.Line, Column: tk.Position.Column}
+		}
+		// point at the first key of a block mapping rather than at the dash
+		if values := mappingValues(v); len(values) > 0 {
+			pos := values[0].Key.GetToken().Position
+			nodes[i].Pos = Position{Line: pos.Line, Column: pos.Column}
+		}
+		for _, mv := range mappingValues(v) {
+			if mv.Key.GetToken().Value == "children" {
+				nodePositions(mv.Value, nodes[i].Children)
+			}
+		}
+	}
+}
+
+// sequenceValues returns the items of a sequence node, if it is one.
+func sequenceValues(node ast.Node) []ast.Node {
+	if seq, ok := unwrap(node).(*ast.SequenceNode); ok {
+		return seq.Values
+	}
+	return nil
+}
+
+// unwrap skips anchors and tags down to the node they annotate.
+func unwrap(node ast.Node) ast.Node {
+	for {
+		switch n := node.(type) {
+		case *ast.AnchorNode:
+			node = n.Value
+		case *ast.TagNode:
+			node = n.Value
+		default:
+			return node
+		}
+	}
+}
+
+// setPositionFile records the spec file name in the position of every
+// structure node of cfg.
+func setPositionFile(cfg *Config, file string) {
+	setNodeFile(cfg.Structure, file)
+	for i := range cfg.Projects {
+		setPositionFile(&cfg.Projects[i], file)
+	}
+}
+
+func setNodeFile(nodes []StructureNode, file string) {
+	for i := range nodes {
+		if nodes[i].Pos.Line > 0 {
+			nodes[i].Pos.File = file
+		}
+		setNodeFile(nodes[i].Children, file)
+	}
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/KoHorizon/ForgeDir/internal/utils"
+)
+
+// Subprojects returns a standalone config for every entry of Projects.
+// Each project inherits the top-level language and portability profile when
+// it has none, sees the top-level variables overridden by its own, and is
+// generated under Output (defaulting to its projectName). Outputs must be relative, traversal-free
+// paths; nested projects are not supported.
+func (c *Config) Subprojects() ([]*Config, error) {
+	projects := make([]*Config, 0, len(c.Projects))
+	for i, p := range c.Projects {
+		if len(p.Projects) > 0 {
+			return nil, fmt.Errorf("project %d (%s): nested projects are not supported", i, p.ProjectName)
+		}
+
+		project := p
+		if project.Language == "" {
+			project.Language = c.Language
+		}
+		if project.Portability == "" {
+			project.Portability = c.Portability
+		}
+		profile, err := utils.PortabilityProfile(project.Portability)
+		if err != nil {
+			return nil, fmt.Errorf("project %d (%s): %w", i, p.ProjectName, err)
+		}
+
+		out, err := cleanOutput(p.Output, p.ProjectName, profile)
+		if err != nil {
+			return nil, fmt.Errorf("project %d (%s): %w", i, p.ProjectName, err)
+		}
+		project.Output = out
+		project.Variables = mergeVariables(c.Variables, p.Variables)
+		projects = append(projects, &project)
+	}
+	return projects, nil
+}
+
+// cleanOutput validates a project's output directory under the project's
+// portability profile and returns it in slash-separated form.
+func cleanOutput(output, projectName string, profile *utils.Portability) (string, error) {
+	if output == "" {
+		output = projectName
+	}
+	if output == "" {
+		return "", fmt.Errorf("output or projectName is required")
+	}
+
+	output = strings.ReplaceAll(output, "\\", "/")
+	if path.IsAbs(output) {
+		return "", fmt.Errorf("invalid output %q: absolute paths are not allowed", output)
+	}
+	output = strings.TrimRight(output, "/")
+	if output == "." {
+		return ".", nil
+	}
+	for _, part := range strings.Split(output, "/") {
+		if err := profile.ValidateName(part); err != nil {
+			return "", fmt.Errorf("invalid output %q: %w", output, err)
+		}
+	}
+	return path.Clean(output), nil
+}
+
+// mergeVariables returns base overridden by override, without modifying either.
+func mergeVariables(base, override map[string]any) map[string]any {
+	if len(base) == 0 {
+		return override
+	}
+	merged := make(map[string]any, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		merged[k] = v
+	}
+	return merged
+}
+
+// OutputsOverlap reports whether two slash-separated project outputs are the
+// same directory or one contains the other.
+func OutputsOverlap(a, b string) bool {
+	if a == "." || b == "." || a == b {
+		return true
+	}
+	return strings.HasPrefix(a, b+"/") || strings.HasPrefix(b, a+"/")
+}
@@ -0,0 +1,91 @@
+package config_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/KoHorizon/ForgeDir/internal/config"
+)
+
+func TestSubprojects_Inheritance(t *testing.T) {
+	cfg := &config.Config{
+		ProjectName: "platform",
+		Language:    "go",
+		Portability: "windows",
+		Variables:   map[string]any{"org": "acme", "db": "postgres"},
+		Projects: []config.Config{
+			{ProjectName: "api", Variables: map[string]any{"db": "mysql"}},
+			{ProjectName: "tools", Language: "python", Output: "services/tools/", Portability: "strict"},
+		},
+	}
+
+	projects, err := cfg.Subprojects()
+	if err != nil {
+		t.Fatalf("Subprojects returned unexpected error: %v", err)
+	}
+	if len(projects) != 2 {
+		t.Fatalf("expected 2 projects, got %d", len(projects))
+	}
+
+	api := projects[0]
+	if api.Output != "api" || api.Language != "go" {
+		t.Errorf("expected api to default to output 'api' and language 'go', got %q / %q", api.Output, api.Language)
+	}
+	if api.Portability != "windows" || projects[1].Portability != "strict" {
+		t.Errorf("expected api to inherit the windows profile and tools to keep strict, got %q / %q", api.Portability, projects[1].Portability)
+	}
+	if api.Variables["org"] != "acme" || api.Variables["db"] != "mysql" {
+		t.Errorf("expected merged variables, got %v", api.Variables)
+	}
+
+	tools := projects[1]
+	if tools.Output != "services/tools" || tools.Language != "python" {
+		t.Errorf("unexpected tools project: output %q, language %q", tools.Output, tools.Language)
+	}
+	if cfg.Variables["db"] != "postgres" {
+		t.Errorf("top-level variables must not be modified, got %v", cfg.Variables)
+	}
+}
+
+func TestSubprojects_RejectsUnsafeOutput(t *testing.T) {
+	for _, output := range []string{"../outside", "a/../../b", "svc/CON", "/etc"} {
+		cfg := &config.Config{Projects: []config.Config{{ProjectName: "x", Output: output}}}
+		if _, err := cfg.Subprojects(); err == nil || !strings.Contains(err.Error(), "invalid output") {
+			t.Errorf("output %q: expected invalid output error, got %v", output, err)
+		}
+	}
+}
+
+func TestSubprojects_OutputFollowsPortability(t *testing.T) {
+	cfg := &config.Config{Projects: []config.Config{{ProjectName: "x", Output: "what?"}}}
+	if _, err := cfg.Subprojects(); err == nil || !strings.Contains(err.Error(), "invalid character '?'") {
+		t.Errorf("portable: expected an invalid character error, got %v", err)
+	}
+
+	cfg.Portability = "posix"
+	projects, err := cfg.Subprojects()
+	if err != nil {
+		t.Fatalf("posix: unexpected error: %v", err)
+	}
+	if projects[0].Output != "what?" || projects[0].Portability != "posix" {
+		t.Errorf("posix: unexpected project %q with profile %q", projects[0].Output, projects[0].Portability)
+	}
+}
+
+func TestOutputsOverlap(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"api", "web", false},
+		{"api", "api", true},
+		{"services", "services/api", true},
+		{"svc", "services", false},
+		{".", "api", true},
+	}
+	for _, tt := range tests {
+		if got := config.OutputsOverlap(tt.a, tt.b); got != tt.want {
+			t.Errorf("OutputsOverlap(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/KoHorizon/ForgeDir/internal/utils"
+)
+
+// Load reads a spec and resolves its includes and `extends` base into a single
+// Config. Include paths are relative to the file declaring them and must stay
+// inside the directory of the root spec, or of the base spec that (directly or
+// through other includes) declares them; an `extends` path is relative to the
+// file declaring it (or absolute, or ~-prefixed). The format of every file is
+// picked by its extension.
+func Load(filename string) (*Config, error) {
+	return LoadFormat(filename, "")
+}
+
+// LoadFormat is Load with the format of the root spec given explicitly (empty
+// to pick it by extension). A filename of Stdin reads the spec from standard
+// input, with relative paths resolved against the working directory.
+func LoadFormat(filename, format string) (*Config, error) {
+	return LoadWith(filename, LoadOptions{Format: format})
+}
+
+// LoadOptions tune how LoadWith reads a spec.
+type LoadOptions struct {
+	// Format is the format of the root spec, empty to pick it by extension.
+	Format string
+	// Portability, when set, overrides the portability profile of the spec
+	// and of every project in it.
+	Portability string
+}
+
+// LoadWith is Load with options.
+func LoadWith(filename string, opts LoadOptions) (*Config, error) {
+	if _, err := utils.PortabilityProfile(opts.Portability); err != nil {
+		return nil, err
+	}
+	cfg, err := LoadConfigFile(filename, opts.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	absFile, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+	r := &resolver{boundary: filepath.Dir(absFile), portability: opts.Portability}
+	if err := r.resolve(cfg, absFile, []string{absFile}); err != nil {
+		return nil, err
+	}
+	if opts.Portability != "" {
+		cfg.Portability = opts.Portability
+		for i := range cfg.Projects {
+			cfg.Projects[i].Portability = opts.Portability
+		}
+	}
+	return cfg, nil
+}
+
+// resolver expands include entries and extends chains recursively. A base
+// spec is resolved by a resolver of its own, bounded by its directory.
+type resolver struct {
+	boundary    string // directory every included file must stay within
+	portability string // profile mount points follow, the spec's own when empty
+}
+
+// profile returns the portability profile of cfg, or the one forced on r.
+func (r *resolver) profile(cfg *Config) (*utils.Portability, error) {
+	name := r.portability
+	if name == "" {
+		name = cfg.Portability
+	}
+	return utils.PortabilityProfile(name)
+}
+
+// resolve anchors the contentFrom and from paths of cfg (declared in file),
+// merges its paths list into its structure, mounts its includes into it, then
+// merges cfg onto its base spec. stack holds the files currently being
+// resolved, to detect cycles.
+func (r *resolver) resolve(cfg *Config, file string, stack []string) error {
+	if err := resolveLocalPaths(cfg.Structure, file); err != nil {
+		return err
+	}
+
+	structure, err := mergePaths(cfg.Structure, cfg.Paths)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	cfg.Structure, cfg.Paths = structure, nil
+
+	if len(cfg.Remove) > 0 && cfg.Extends == "" {
+		return fmt.Errorf("%s: remove needs extends, there is no base spec to remove nodes from", file)
+	}
+
+	var profile *utils.Portability
+	if len(cfg.Include) > 0 {
+		if profile, err = r.profile(cfg); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	for _, inc := range cfg.Include {
+		fragment, err := r.loadInclude(inc, file, stack)
+		if err != nil {
+			return err
+		}
+
+		at, err := mountPoint(inc.At, profile)
+		if err != nil {
+			return fmt.Errorf("include %q in %s: %w", inc.Path, file, err)
+		}
+		cfg.Structure = mount(cfg.Structure, at, fragment.Structure)
+		// the including spec wins over its fragments
+		cfg.Variables = mergeVariables(fragment.Variables, cfg.Variables)
+	}
+	cfg.Include = nil
+
+	for i := range cfg.Projects {
+		// projects without a profile of their own inherit the spec's
+		pr := *r
+		if pr.portability == "" && cfg.Projects[i].Portability == "" {
+			pr.portability = cfg.Portability
+		}
+		if err := pr.resolve(&cfg.Projects[i], file, stack); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Extends != "" {
+		base, err := r.loadBase(cfg.Extends, file, stack)
+		if err != nil {
+			return err
+		}
+		merged, err := Merge(base, cfg)
+		if err != nil {
+			return fmt.Errorf("extending %q in %s: %w", cfg.Extends, file, err)
+		}
+		*cfg = *merged
+	}
+	return nil
+}
+
+// loadInclude reads and resolves the fragment referenced by inc.
+func (r *resolver) loadInclude(inc Include, from string, stack []string) (*Config, error) {
+	if inc.Path == "" {
+		return nil, fmt.Errorf("include in %s: path is required", from)
+	}
+	if filepath.IsAbs(inc.Path) {
+		return nil, fmt.Errorf("include %q in %s: absolute paths are not allowed", inc.Path, from)
+	}
+
+	target, err := utils.SanitizePath(r.boundary, filepath.Join(filepath.Dir(from), inc.Path))
+	if err != nil {
+		return nil, fmt.Errorf("include %q in %s: %w", inc.Path, from, err)
+	}
+	return r.loadFile(target, stack, "include", fmt.Sprintf("include %q in %s", inc.Path, from))
+}
+
+// loadBase reads and resolves the base spec named by an extends key.
+func (r *resolver) loadBase(extends, from string, stack []string) (*Config, error) {
+	target := extends
+	if strings.HasPrefix(extends, "~") || filepath.IsAbs(extends) {
+		expanded, err := utils.ExpandPath(extends)
+		if err != nil {
+			return nil, fmt.Errorf("extends %q in %s: %w", extends, from, err)
+		}
+		target = expanded
+	} else {
+		target = filepath.Join(filepath.Dir(from), extends)
+	}
+	target, err := filepath.Abs(target)
+	if err != nil {
+		return nil, fmt.Errorf("extends %q in %s: %w", extends, from, err)
+	}
+	// the base's own includes stay within its directory, not the child's
+	base := &resolver{boundary: filepath.Dir(target), portability: r.portability}
+	return base.loadFile(target, stack, "extends", fmt.Sprintf("extends %q in %s", extends, from))
+}
+
+// loadFile reads and resolves target unless it is already being resolved.
+// kind ("include" or "extends") names the reference in cycle errors.
+func (r *resolver) loadFile(target string, stack []string, kind, what string) (*Config, error) {
+	for _, f := range stack {
+		if f == target {
+			return nil, fmt.Errorf("%s cycle: %s -> %s", kind, strings.Join(stack, " -> "), target)
+		}
+	}
+
+	cfg, err := LoadConfigFile(target, "")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", what, err)
+	}
+	if err := r.resolve(cfg, target, append(stack, target)); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// resolveLocalPaths makes every contentFrom and from path in nodes absolute.
+// Paths are relative to the spec file declaring them and must stay inside its directory.
+func resolveLocalPaths(nodes []StructureNode, file string) error {
+	for i := range nodes {
+		n := &nodes[i]
+		var err error
+		if n.ContentFrom, err = localPath("contentFrom", n.ContentFrom, file); err != nil {
+			return err
+		}
+		if n.From, err = localPath("from", n.From, file); err != nil {
+			return err
+		}
+		if err := resolveLocalPaths(n.Children, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// localPath anchors p, the value of key in file, to the directory of file.
+func localPath(key, p, file string) (string, error) {
+	if p == "" {
+		return "", nil
+	}
+	if filepath.IsAbs(p) {
+		return "", fmt.Errorf("%s %q in %s: absolute paths are not allowed", key, p, file)
+	}
+	dir := filepath.Dir(file)
+	target, err := utils.SanitizePath(dir, filepath.Join(dir, p))
+	if err != nil {
+		return "", fmt.Errorf("%s %q in %s: %w", key, p, file, err)
+	}
+	return target, nil
+}
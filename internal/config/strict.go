@@ -0,0 +1,339 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+)
+
+// SpecError is a problem at a position of a spec file. Line is 0 when the
+// position is unknown.
+type SpecError struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+	Snippet string // the offending source line with a caret under Column
+}
+
+func (e *SpecError) Error() string {
+	var b strings.Builder
+	if e.File != "" {
+		b.WriteString(e.File)
+		b.WriteString(":")
+	}
+	if e.Line > 0 {
+		fmt.Fprintf(&b, "%d:%d:", e.Line, e.Column)
+	}
+	if b.Len() > 0 {
+		b.WriteString(" ")
+	}
+	b.WriteString(e.Message)
+	if e.Snippet != "" {
+		b.WriteString("\n")
+		b.WriteString(e.Snippet)
+	}
+	return b.String()
+}
+
+// SpecErrors lists every problem found in a spec.
+type SpecErrors []*SpecError
+
+func (l SpecErrors) Error() string {
+	msgs := make([]string, len(l))
+	for i, e := range l {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// setFile records the spec file name on every SpecError in err.
+func setFile(err error, file string) error {
+	var list SpecErrors
+	if errors.As(err, &list) {
+		for _, e := range list {
+			e.File = file
+		}
+		return err
+	}
+	var single *SpecError
+	if errors.As(err, &single) {
+		single.File = file
+	}
+	return err
+}
+
+// newSpecError builds a SpecError at line:column of src, with a snippet.
+func newSpecError(src []byte, line, column int, msg string) *SpecError {
+	e := &SpecError{Line: line, Column: column, Message: msg}
+	lines := strings.Split(string(src), "\n")
+	if line < 1 || line > len(lines) {
+		return e
+	}
+	text := strings.TrimRight(lines[line-1], "\r")
+	prefix := fmt.Sprintf("%5d | ", line)
+	pad := ""
+	if column > 1 && column-1 <= len(text) {
+		// keep tabs so the caret lines up
+		pad = strings.Map(func(r rune) rune {
+			if r == '\t' {
+				return '\t'
+			}
+			return ' '
+		}, text[:column-1])
+	}
+	e.Snippet = prefix + text + "\n" + strings.Repeat(" ", len(prefix)-2) + "| " + pad + "^"
+	return e
+}
+
+// yamlError turns a goccy error into a SpecError at the position it reports.
+func yamlError(src []byte, err error) error {
+	var yerr yaml.Error
+	if errors.As(err, &yerr) && yerr.GetToken() != nil {
+		pos := yerr.GetToken().Position
+		return SpecErrors{newSpecError(src, pos.Line, pos.Column, yerr.GetMessage())}
+	}
+	return err
+}
+
+// checkKeys reports every mapping key in a YAML (or JSON) spec that Config
+// does not know, with a suggestion when it looks like a misspelled key.
+func checkKeys(src []byte) error {
+	file, err := parser.ParseBytes(src, 0)
+	if err != nil {
+		return yamlError(src, err)
+	}
+
+	c := &keyChecker{src: src}
+	for _, doc := range file.Docs {
+		c.walk(doc.Body, reflect.TypeOf(Config{}))
+	}
+	if len(c.errs) > 0 {
+		return c.errs
+	}
+	return nil
+}
+
+var (
+	includeType  = reflect.TypeOf(Include{})
+	includesType = reflect.TypeOf(Includes{})
+)
+
+type keyChecker struct {
+	src  []byte
+	errs SpecErrors
+}
+
+// walk checks node against the Go type it decodes into.
+func (c *keyChecker) walk(node ast.Node, t reflect.Type) {
+	if node == nil {
+		return
+	}
+	switch n := node.(type) {
+	case *ast.AnchorNode:
+		c.walk(n.Value, t)
+		return
+	case *ast.TagNode:
+		c.walk(n.Value, t)
+		return
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	// Includes take a single include as well as a list
+	if t == includesType {
+		if seq, ok := node.(*ast.SequenceNode); ok {
+			for _, v := range seq.Values {
+				c.walk(v, includeType)
+			}
+			return
+		}
+		c.walk(node, includeType)
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		fields := yamlFields(t)
+		for _, mv := range mappingValues(node) {
+			if _, ok := mv.Key.(*ast.MergeKeyNode); ok {
+				continue
+			}
+			key := mv.Key.GetToken().Value
+			field, ok := fields[key]
+			if !ok {
+				pos := mv.Key.GetToken().Position
+				c.errs = append(c.errs, newSpecError(c.src, pos.Line, pos.Column, unknownKeyMessage(key, fields)))
+				continue
+			}
+			c.walk(mv.Value, field)
+		}
+	case reflect.Slice:
+		if seq, ok := node.(*ast.SequenceNode); ok {
+			for _, v := range seq.Values {
+				c.walk(v, t.Elem())
+			}
+		}
+	}
+}
+
+// mappingValues returns the key/value pairs of a mapping node, if it is one.
+func mappingValues(node ast.Node) []*ast.MappingValueNode {
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		return n.Values
+	case *ast.MappingValueNode:
+		return []*ast.MappingValueNode{n}
+	}
+	return nil
+}
+
+// yamlFields maps the YAML keys of a struct to their field types.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// unknownKeyMessage describes an unknown key, suggesting the closest known one.
+func unknownKeyMessage(key string, fields map[string]reflect.Type) string {
+	known := make([]string, 0, len(fields))
+	for name := range fields {
+		known = append(known, name)
+	}
+	sort.Strings(known)
+
+	if s := suggest(key, known); s != "" {
+		return fmt.Sprintf("unknown key %q (did you mean %q?)", key, s)
+	}
+	return fmt.Sprintf("unknown key %q (known keys: %s)", key, strings.Join(known, ", "))
+}
+
+// suggest returns the candidate closest to word, or "" when none is close
+// enough to be a likely typo.
+func suggest(word string, candidates []string) string {
+	best, bestDist := "", -1
+	lower := strings.ToLower(word)
+	for _, c := range candidates {
+		d := editDistance(lower, strings.ToLower(c))
+		if bestDist < 0 || d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	limit := len(word) / 3
+	if limit < 1 {
+		limit = 1
+	}
+	if bestDist < 0 || bestDist > limit {
+		return ""
+	}
+	return best
+}
+
+// editDistance is the optimal string alignment distance: insertions,
+// deletions, substitutions and swaps of adjacent letters all cost 1.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+// tomlPositions moves the errors found in the YAML a TOML spec was converted
+// to back onto the TOML source: an unknown key is looked up where it is
+// assigned or used as a table name. Other positions are dropped.
+func tomlPositions(src []byte, err error) error {
+	var list SpecErrors
+	if !errors.As(err, &list) {
+		return err
+	}
+
+	seen := map[string]int{}
+	out := make(SpecErrors, len(list))
+	for i, e := range list {
+		out[i] = &SpecError{Message: e.Message}
+		key, ok := unknownKey(e.Message)
+		if !ok {
+			continue
+		}
+		line, col := tomlKeyPosition(src, key, seen[key])
+		seen[key]++
+		if line > 0 {
+			out[i] = newSpecError(src, line, col, e.Message)
+		}
+	}
+	// keys of the converted YAML are sorted; report in source order
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Line > 0 && (out[j].Line == 0 || out[i].Line < out[j].Line)
+	})
+	return out
+}
+
+var unknownKeyPattern = regexp.MustCompile(`^unknown key "([^"]+)"`)
+
+func unknownKey(msg string) (string, bool) {
+	m := unknownKeyPattern.FindStringSubmatch(msg)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+// tomlKeyPosition finds the nth place key appears as a key in TOML source:
+// `key = ...`, `[a.key]`, `[[a.key]]` or inside an inline table.
+func tomlKeyPosition(src []byte, key string, nth int) (int, int) {
+	re := regexp.MustCompile(`(^|[\s{,.\[])"?(` + regexp.QuoteMeta(key) + `)"?\s*(=|\]|\.)`)
+	for i, line := range strings.Split(string(src), "\n") {
+		for _, m := range re.FindAllStringSubmatchIndex(line, -1) {
+			if nth == 0 {
+				col := m[4] + 1
+				if m[4] > 0 && line[m[4]-1] == '"' {
+					col--
+				}
+				return i + 1, col
+			}
+			nth--
+		}
+	}
+	return 0, 0
+}
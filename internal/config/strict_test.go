@@ -0,0 +1,159 @@
+package config_test
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KoHorizon/ForgeDir/internal/config"
+)
+
+func TestDecode_UnknownKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		spec   string
+		want   []string // "line:column: message" of every error, in order
+	}{
+		{
+			name:   "misspelled keys",
+			format: config.FormatYAML,
+			spec: `projectName: p
+langauge: go
+structure:
+  - type: dir
+    name: src
+    chidren: []
+  - tpye: file
+    name: main.go
+`,
+			want: []string{
+				`2:1: unknown key "langauge" (did you mean "language"?)`,
+				`6:5: unknown key "chidren" (did you mean "children"?)`,
+				`7:5: unknown key "tpye" (did you mean "type"?)`,
+			},
+		},
+		{
+			name:   "no close key",
+			format: config.FormatYAML,
+			spec: `projectName: p
+structure:
+  - type: file
+    name: a
+    colour: red
+`,
+			want: []string{`5:5: unknown key "colour" (known keys: children, content, contentFrom, forEach, from, language, mode, name, target, template, type, when)`},
+		},
+		{
+			name:   "include mapping and monorepo project",
+			format: config.FormatYAML,
+			spec: `projectName: p
+include:
+  - {path: a.yaml, mount: x}
+projects:
+  - projectName: api
+    outptu: services/api
+`,
+			want: []string{
+				`3:20: unknown key "mount" (known keys: at, path)`,
+				`6:5: unknown key "outptu" (did you mean "output"?)`,
+			},
+		},
+		{
+			name:   "case mismatch",
+			format: config.FormatYAML,
+			spec:   "projectname: p\n",
+			want:   []string{`1:1: unknown key "projectname" (did you mean "projectName"?)`},
+		},
+		{
+			name:   "json",
+			format: config.FormatJSON,
+			spec:   "{\"projectName\": \"p\",\n \"structure\": [{\"type\": \"file\", \"nmae\": \"a\"}]}",
+			want:   []string{`2:33: unknown key "nmae" (did you mean "name"?)`},
+		},
+		{
+			name:   "toml",
+			format: config.FormatTOML,
+			spec: `projectName = "p"
+
+[[structure]]
+tpye = "file"
+name = "a"
+
+  [[structure.chidren]]
+  name = "b"
+`,
+			want: []string{
+				`4:1: unknown key "tpye" (did you mean "type"?)`,
+				`7:15: unknown key "chidren" (did you mean "children"?)`,
+			},
+		},
+		{
+			name:   "variables are free-form",
+			format: config.FormatYAML,
+			spec:   "projectName: p\nvariables:\n  anything: {goes: here}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := config.Decode([]byte(tt.spec), tt.format)
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var list config.SpecErrors
+			if !errors.As(err, &list) {
+				t.Fatalf("expected SpecErrors, got %v", err)
+			}
+			var got []string
+			for _, e := range list {
+				got = append(got, strings.SplitN(e.Error(), "\n", 2)[0])
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("unexpected errors:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestLoad_ErrorHasFileAndSnippet(t *testing.T) {
+	dir := writeSpecs(t, map[string]string{
+		"spec.yaml": "projectName: p\nstructure:\n  - type: file\n    chidren: []\n",
+	})
+	file := filepath.Join(dir, "spec.yaml")
+
+	_, err := config.Load(file)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	want := file + `:4:5: unknown key "chidren" (did you mean "children"?)
+    4 |     chidren: []
+      |     ^`
+	if err.Error() != want {
+		t.Errorf("unexpected error:\n%s\nwant:\n%s", err, want)
+	}
+}
+
+func TestDecode_SyntaxAndTypeErrorsHavePositions(t *testing.T) {
+	tests := []struct {
+		format string
+		spec   string
+		want   string
+	}{
+		{format: config.FormatYAML, spec: "projectName: p\nstructure:\n  - type: file\n    children: 3\n", want: "4:15: "},
+		{format: config.FormatYAML, spec: "projectName: p\nstructure: [a\n", want: "2:12: "},
+		{format: config.FormatJSON, spec: "{\"projectName\": \"p\"\n \"language\": \"go\"}", want: "2:3: invalid JSON"},
+		{format: config.FormatTOML, spec: "projectName = \"p\"\nlanguage = \n", want: "2:"},
+	}
+	for _, tt := range tests {
+		_, err := config.Decode([]byte(tt.spec), tt.format)
+		if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+			t.Errorf("%s: expected error starting with %q, got %v", tt.format, tt.want, err)
+		}
+	}
+}
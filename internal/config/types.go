@@ -1,19 +1,71 @@
 package config
 
 const (
-	TypeDir  = "dir"
-	TypeFile = "file"
+	TypeDir     = "dir"
+	TypeFile    = "file"
+	TypeSymlink = "symlink"
+	TypeCopy    = "copy"
 	// Other type to expand
 )
 
 type StructureNode struct {
 	Type     string          `yaml:"type"`
 	Name     string          `yaml:"name"`
+	Language string          `yaml:"language,omitempty"` // overrides Config.Language for this subtree
+	When     string          `yaml:"when,omitempty"`     // condition over variables; the node is skipped when false
+	ForEach  string          `yaml:"forEach,omitempty"`  // list expression; the node is repeated once per item
 	Children []StructureNode `yaml:"children,omitempty"`
+
+	// File content given in the spec; both are rendered as templates and take
+	// priority over template lookup. ContentFrom is relative to the spec file.
+	Content     *string `yaml:"content,omitempty"`
+	ContentFrom string  `yaml:"contentFrom,omitempty"`
+
+	// Template picks a template by name ("handler.go.tmpl", "rest/handler")
+	// instead of matching on the file name.
+	Template string `yaml:"template,omitempty"`
+
+	// Mode sets the permissions of the file or directory ("0755"). Without it
+	// dirs get 0755 and files 0644, or 0755 when their content starts with "#!".
+	Mode Mode `yaml:"mode,omitempty"`
+
+	// Target is where a symlink points, relative to the link's directory
+	// ("../shared/.golangci.yml", "v2"). It must stay inside the project.
+	Target string `yaml:"target,omitempty"`
+
+	// From is the local file or directory a copy node copies byte for byte,
+	// without template processing. It is relative to the spec file.
+	From string `yaml:"from,omitempty"`
+
+	Item  any      `yaml:"-"` // current forEach item, set by Config.Expand
+	Items []any    `yaml:"-"` // items of the enclosing forEach loops, outermost first
+	Pos   Position `yaml:"-"` // where the node is declared, set when decoding
 }
 
 type Config struct {
+	// Composition, resolved by Load: Extends names a base spec this one is
+	// merged onto, Remove lists base paths to drop, Include mounts fragments.
+	Extends string   `yaml:"extends,omitempty"`
+	Remove  []string `yaml:"remove,omitempty"`
+	Include Includes `yaml:"include,omitempty"`
+
 	ProjectName string          `yaml:"projectName"`
 	Language    string          `yaml:"language"`
+	Variables   map[string]any  `yaml:"variables,omitempty"`
 	Structure   []StructureNode `yaml:"structure"`
+
+	// Portability names the path rules node names must follow: posix,
+	// windows, portable (the default) or strict. See utils.PortabilityProfile.
+	Portability string `yaml:"portability,omitempty"`
+
+	// Paths is a compact alternative to Structure ("cmd/server/main.go",
+	// "internal/handlers/" for a directory); Load merges it into Structure.
+	Paths []string `yaml:"paths,omitempty"`
+
+	// Monorepo specs: each project is generated under Output (relative to the
+	// spec's output directory), while Structure above holds the shared root files.
+	Output   string   `yaml:"output,omitempty"`
+	Projects []Config `yaml:"projects,omitempty"`
+
+	expanded bool // set by Expand
 }
@@ -0,0 +1,226 @@
+package generator
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const cmakeListsName = "CMakeLists.txt"
+
+// cmakeSourceExts are the extensions listed as sources in generated
+// CMakeLists.txt files, by language of the template set; other sets list
+// every C and C++ source.
+var cmakeSourceExts = map[string][]string{
+	"c":   {".c"},
+	"cpp": {".cpp", ".cc", ".cxx"},
+	"":    {".c", ".cpp", ".cc", ".cxx"},
+}
+
+// includeGuard builds a header guard such as MYPROJ_INCLUDE_NET_SOCKET_H_
+// from the project name and the project-relative path of the header. A guard
+// that would start with a digit is prefixed with H_, to stay an identifier.
+func includeGuard(projectName, relPath string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(projectName + "/" + relPath) {
+		if r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	guard := strings.TrimLeft(b.String(), "_") + "_"
+	if guard[0] >= '0' && guard[0] <= '9' {
+		guard = "H_" + guard
+	}
+	return guard
+}
+
+// CMakeTarget names the target of this CMakeLists.txt: the project for the
+// top-level one, otherwise the directory path with its separators replaced
+// ("src/util" -> "src_util"), so that src/util and lib/util do not define
+// the same target.
+func (d templateData) CMakeTarget() string {
+	return d.cmakeTarget(path.Dir(d.Path))
+}
+
+// cmakeTarget names the target of the CMakeLists.txt in dir.
+func (d templateData) cmakeTarget(dir string) string {
+	name := dir
+	if name == "." {
+		name = d.ProjectName
+	}
+	var b strings.Builder
+	for _, r := range name {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune(".+-_", r) {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// CMakeTargets renders the part shared by the C and C++ CMakeLists.txt
+// templates: add_subdirectory for CMakeSubdirs, then an executable (top level)
+// or a library (below it) built from CMakeSources, linked to the libraries of
+// the subdirectories.
+func (d templateData) CMakeTargets() string {
+	var b strings.Builder
+	subdirs := d.CMakeSubdirs()
+	for _, sub := range subdirs {
+		fmt.Fprintf(&b, "add_subdirectory(%s)\n", sub)
+	}
+
+	sources := d.CMakeSources()
+	if len(sources) == 0 {
+		return b.String()
+	}
+	if len(subdirs) > 0 {
+		b.WriteString("\n")
+	}
+	target := d.CMakeTarget()
+	topLevel := path.Dir(d.Path) == "."
+	if topLevel {
+		fmt.Fprintf(&b, "add_executable(%s", target)
+	} else {
+		fmt.Fprintf(&b, "add_library(%s", target)
+	}
+	for _, src := range sources {
+		fmt.Fprintf(&b, "\n    %s", src)
+	}
+	b.WriteString("\n)\n")
+	if !topLevel {
+		fmt.Fprintf(&b, "target_include_directories(%s PUBLIC ${CMAKE_CURRENT_SOURCE_DIR})\n", target)
+	}
+	if libs := d.cmakeLibraries(path.Dir(d.Path)); len(libs) > 0 {
+		fmt.Fprintf(&b, "target_link_libraries(%s PRIVATE %s)\n", target, strings.Join(libs, " "))
+	}
+	return b.String()
+}
+
+// cmakeLibraries lists the targets of the subdirectories the CMakeLists.txt
+// in dir pulls in. A subdirectory without sources has no target, so the
+// libraries below it are linked instead.
+func (d templateData) cmakeLibraries(dir string) []string {
+	var libs []string
+	for _, sub := range d.cmakeSubdirs(dir) {
+		if len(d.cmakeSources(sub)) > 0 {
+			libs = append(libs, d.cmakeTarget(sub))
+		} else {
+			libs = append(libs, d.cmakeLibraries(sub)...)
+		}
+	}
+	return libs
+}
+
+// Sibling returns the name of the first file next to this one that has the same
+// base name and one of the given extensions, or "" if the spec has none.
+// A source template can use it to include its header: {{ with .Sibling ".h" }}.
+func (d templateData) Sibling(exts ...string) string {
+	dir := path.Dir(d.Path)
+	for _, ext := range exts {
+		candidate := path.Join(dir, d.FileName+ext)
+		if candidate != d.Path && containsString(d.Files, candidate) {
+			return d.FileName + ext
+		}
+	}
+	return ""
+}
+
+// CMakeSources lists the sources owned by this CMakeLists.txt, relative to its
+// directory: C sources for the c set, C++ sources for the cpp set. Sources
+// below a subdirectory with its own CMakeLists.txt belong to that
+// subdirectory instead.
+func (d templateData) CMakeSources() []string {
+	return d.cmakeSources(path.Dir(d.Path))
+}
+
+// cmakeSources lists the sources owned by the CMakeLists.txt in dir.
+func (d templateData) cmakeSources(dir string) []string {
+	owners := cmakeDirs(d.Files)
+	exts, ok := cmakeSourceExts[d.Language]
+	if !ok {
+		exts = cmakeSourceExts[""]
+	}
+
+	var sources []string
+	for _, f := range d.Files {
+		if !slices.Contains(exts, path.Ext(f)) {
+			continue
+		}
+		if nearestCMakeDir(path.Dir(f), owners) == dir {
+			sources = append(sources, relativeTo(dir, f))
+		}
+	}
+	return sources
+}
+
+// CMakeSubdirs lists the subdirectories that have their own CMakeLists.txt and
+// must be pulled in with add_subdirectory from this one.
+func (d templateData) CMakeSubdirs() []string {
+	dir := path.Dir(d.Path)
+	var subdirs []string
+	for _, sub := range d.cmakeSubdirs(dir) {
+		subdirs = append(subdirs, relativeTo(dir, sub))
+	}
+	return subdirs
+}
+
+// cmakeSubdirs lists, as project paths, the subdirectories the
+// CMakeLists.txt in dir pulls in.
+func (d templateData) cmakeSubdirs(dir string) []string {
+	owners := cmakeDirs(d.Files)
+
+	var subdirs []string
+	for sub := range owners {
+		if sub == dir {
+			continue
+		}
+		if nearestCMakeDir(path.Dir(sub), owners) == dir {
+			subdirs = append(subdirs, sub)
+		}
+	}
+	sort.Strings(subdirs)
+	return subdirs
+}
+
+// cmakeDirs returns the set of directories that contain a CMakeLists.txt.
+func cmakeDirs(files []string) map[string]bool {
+	dirs := map[string]bool{}
+	for _, f := range files {
+		if path.Base(f) == cmakeListsName {
+			dirs[path.Dir(f)] = true
+		}
+	}
+	return dirs
+}
+
+// nearestCMakeDir walks up from dir to the closest directory owning a
+// CMakeLists.txt, or "" if there is none.
+func nearestCMakeDir(dir string, owners map[string]bool) string {
+	for {
+		if owners[dir] {
+			return dir
+		}
+		if dir == "." || dir == "/" {
+			return ""
+		}
+		dir = path.Dir(dir)
+	}
+}
+
+// relativeTo strips the dir prefix from a slash-separated project path.
+func relativeTo(dir, p string) string {
+	if dir == "." {
+		return p
+	}
+	return strings.TrimPrefix(p, dir+"/")
+}
+
+func containsString(list []string, s string) bool {
+	i := sort.SearchStrings(list, s)
+	return i < len(list) && list[i] == s
+}
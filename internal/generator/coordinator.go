@@ -1,6 +1,7 @@
 package generator
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -20,13 +21,33 @@ func NewCoordinator(gens []Generator) *Coordinator {
 	return &Coordinator{LanguageBoilerplate: m}
 }
 
-func (c *Coordinator) RunBoilerplateGeneration(cfg *config.Config, projectRoot string) error {
-	gen, ok := c.LanguageBoilerplate[cfg.Language]
-	if !ok {
-		return errors.New("no boilerplate generator found for " + cfg.Language)
+// RunBoilerplateGeneration runs the generator of every language the spec
+// uses. Each one gets the whole spec and renders the files whose effective
+// language (a node's `language` key, inherited by its subtree, or
+// cfg.Language) is its own. All generators are looked up before anything is
+// generated.
+// It stops with the context's error once ctx is cancelled.
+func (c *Coordinator) RunBoilerplateGeneration(ctx context.Context, cfg *config.Config, projectRoot string) error {
+	cfg, err := cfg.Expand()
+	if err != nil {
+		return err
 	}
-	if err := gen.Generate(cfg, projectRoot); err != nil {
-		return fmt.Errorf("boilerplate generation failed for %s: %w", cfg.Language, err)
+
+	languages := cfg.Languages()
+	gens := make([]Generator, 0, len(languages))
+	for _, lang := range languages {
+		gen, ok := c.LanguageBoilerplate[lang]
+		if !ok {
+			return errors.New("no boilerplate generator found for " + lang)
+		}
+		gens = append(gens, gen)
+	}
+
+	for i, gen := range gens {
+		lang := languages[i]
+		if err := gen.Generate(ctx, cfg, projectRoot); err != nil {
+			return fmt.Errorf("boilerplate generation failed for %s: %w", lang, err)
+		}
 	}
 	return nil
 }
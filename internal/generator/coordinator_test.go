@@ -1,6 +1,7 @@
 package generator_test
 
 import (
+	"context"
 	"errors"
 	"strings"
 	"testing"
@@ -12,12 +13,14 @@ import (
 type dummyGen struct {
 	lang   string
 	called bool
+	got    *config.Config
 	err    error
 }
 
 func (d *dummyGen) GetLanguage() string { return d.lang }
-func (d *dummyGen) Generate(cfg *config.Config, root string) error {
+func (d *dummyGen) Generate(ctx context.Context, cfg *config.Config, root string) error {
 	d.called = true
+	d.got = cfg
 	return d.err
 }
 
@@ -25,7 +28,7 @@ func TestRunBoilerplate_Success(t *testing.T) {
 	gen := &dummyGen{lang: "go"}
 	coord := generator.NewCoordinator([]generator.Generator{gen})
 
-	err := coord.RunBoilerplateGeneration(&config.Config{Language: "go"}, "/some/root")
+	err := coord.RunBoilerplateGeneration(context.Background(), &config.Config{Language: "go"}, "/some/root")
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -36,7 +39,7 @@ func TestRunBoilerplate_Success(t *testing.T) {
 
 func TestRunBoilerplate_NoGenerator(t *testing.T) {
 	coord := generator.NewCoordinator(nil)
-	err := coord.RunBoilerplateGeneration(&config.Config{Language: "js"}, "/any")
+	err := coord.RunBoilerplateGeneration(context.Background(), &config.Config{Language: "js"}, "/any")
 	if err == nil || !strings.Contains(err.Error(), "no boilerplate generator found") {
 		t.Errorf("expected no-generator error, got %v", err)
 	}
@@ -46,8 +49,56 @@ func TestRunBoilerplate_GeneratorError(t *testing.T) {
 	gen := &dummyGen{lang: "py", err: errors.New("boom")}
 	coord := generator.NewCoordinator([]generator.Generator{gen})
 
-	err := coord.RunBoilerplateGeneration(&config.Config{Language: "py"}, "/root")
+	err := coord.RunBoilerplateGeneration(context.Background(), &config.Config{Language: "py"}, "/root")
 	if err == nil || !strings.Contains(err.Error(), "boilerplate generation failed") {
 		t.Errorf("expected generate-error, got %v", err)
 	}
 }
+
+func TestRunBoilerplate_PerNodeLanguage(t *testing.T) {
+	goGen := &dummyGen{lang: "go"}
+	pyGen := &dummyGen{lang: "python"}
+	coord := generator.NewCoordinator([]generator.Generator{goGen, pyGen})
+
+	cfg := &config.Config{
+		Language: "go",
+		Structure: []config.StructureNode{
+			{Type: config.TypeFile, Name: "main.go"},
+			{Type: config.TypeDir, Name: "scripts", Language: "python", Children: []config.StructureNode{
+				{Type: config.TypeFile, Name: "deploy.py"},
+				{Type: config.TypeFile, Name: "helper.go", Language: "go"},
+			}},
+		},
+	}
+
+	if err := coord.RunBoilerplateGeneration(context.Background(), cfg, "/root"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// each generator picks its own files from the whole spec
+	for _, gen := range []*dummyGen{goGen, pyGen} {
+		if !gen.called || gen.got.Language != "go" || len(gen.got.Structure) != 2 {
+			t.Errorf("expected the %s generator to get the whole spec, got %+v", gen.lang, gen.got)
+		}
+	}
+}
+
+func TestRunBoilerplate_UnknownNodeLanguage(t *testing.T) {
+	gen := &dummyGen{lang: "go"}
+	coord := generator.NewCoordinator([]generator.Generator{gen})
+
+	cfg := &config.Config{
+		Language: "go",
+		Structure: []config.StructureNode{
+			{Type: config.TypeDir, Name: "web", Language: "typescript"},
+		},
+	}
+
+	err := coord.RunBoilerplateGeneration(context.Background(), cfg, "/root")
+	if err == nil || !strings.Contains(err.Error(), "no boilerplate generator found for typescript") {
+		t.Errorf("expected no-generator error, got %v", err)
+	}
+	if gen.called {
+		t.Error("no generator should run when a language is missing")
+	}
+}
@@ -1,6 +1,7 @@
 package generator
 
 import (
+	"context"
 	"embed"
 	"fmt"
 
@@ -11,25 +12,35 @@ import (
 // Generator is your interface for scaffolding.
 type Generator interface {
 	GetLanguage() string
-	Generate(cfg *config.Config, root string) error
+	Generate(ctx context.Context, cfg *config.Config, root string) error
 }
 
-//go:embed templates/*/*.tmpl
+//go:embed templates
 var tmplFS embed.FS
 
 // GeneratorFactory creates generators for available languages
 type GeneratorFactory struct {
 	fs             builder.FileSystem
 	templateSource TemplateSource
+	slots          chan struct{} // shared by every generator it creates
 }
 
 func NewGeneratorFactory(fs builder.FileSystem, templateSource TemplateSource) *GeneratorFactory {
 	return &GeneratorFactory{
 		fs:             fs,
 		templateSource: templateSource,
+		slots:          make(chan struct{}, 1),
 	}
 }
 
+// SetJobs sets how many files the generators it creates render and write at
+// once, all together: they share one set of slots, so the bound holds however
+// many projects and languages run at the same time. The file system must then
+// be safe for concurrent use. Call it before creating the generators.
+func (f *GeneratorFactory) SetJobs(jobs int) {
+	f.slots = make(chan struct{}, max(1, jobs))
+}
+
 // CreateAvailableGenerators scans available templates and creates generators
 func (f *GeneratorFactory) CreateAvailableGenerators() ([]Generator, error) {
 	languages, err := f.templateSource.ListLanguages()
@@ -50,19 +61,20 @@ func (f *GeneratorFactory) CreateAvailableGenerators() ([]Generator, error) {
 }
 
 func (f *GeneratorFactory) createGeneratorForLanguage(language string) (Generator, error) {
-	tmpl, err := f.templateSource.ParseTemplates(language)
+	gen, err := newGenerator(language, f.fs, f.templateSource)
 	if err != nil {
-		return nil, fmt.Errorf("parsing templates for %s: %w", language, err)
+		return nil, err
 	}
-
-	return &GenericGenerator{
-		lang: language,
-		tmpl: tmpl,
-		fs:   f.fs,
-	}, nil
+	gen.slots = f.slots
+	return gen, nil
 }
 
 // GetTemplatesForLanguage returns templates for a specific language
 func (f *GeneratorFactory) GetTemplatesForLanguage(language string) ([]string, error) {
 	return f.templateSource.ListTemplates(language)
 }
+
+// GetAssetsForLanguage returns the files a language's template set copies verbatim
+func (f *GeneratorFactory) GetAssetsForLanguage(language string) ([]string, error) {
+	return f.templateSource.ListAssets(language)
+}
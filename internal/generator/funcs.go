@@ -0,0 +1,30 @@
+package generator
+
+import (
+	"path"
+	"strings"
+	"text/template"
+
+	"github.com/KoHorizon/ForgeDir/internal/config"
+)
+
+// newTemplate returns an empty template set with the helper functions of
+// config.TemplateFuncs registered. They must be registered before parsing, so
+// every TemplateSource uses newTemplate.
+func newTemplate() *template.Template {
+	return template.New("").Funcs(config.TemplateFuncs())
+}
+
+// javaPackage derives a Java package name from a slash-separated, project-relative
+// directory. Everything below a "src/<set>/java" source root becomes the package,
+// so "src/main/java/com/acme/foo" gives "com.acme.foo". Directories outside a
+// source root have no package.
+func javaPackage(dir string) string {
+	parts := strings.Split(path.Clean(dir), "/")
+	for i := 0; i+2 < len(parts); i++ {
+		if parts[i] == "src" && parts[i+2] == "java" {
+			return strings.Join(parts[i+3:], ".")
+		}
+	}
+	return ""
+}
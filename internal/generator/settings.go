@@ -0,0 +1,42 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/goccy/go-yaml"
+)
+
+// ManifestName is the optional file at the top of a template set holding its
+// settings. It is neither a template nor an asset.
+const ManifestName = "template-set.yaml"
+
+// TemplateSettings are the settings a template set declares in its manifest.
+type TemplateSettings struct {
+	// ExecutableScripts makes files whose content starts with a shebang
+	// executable unless their node sets a mode. Defaults to true.
+	ExecutableScripts *bool `yaml:"executableScripts"`
+}
+
+// executableScripts reports whether the shebang rule applies.
+func (s TemplateSettings) executableScripts() bool {
+	return s.ExecutableScripts == nil || *s.ExecutableScripts
+}
+
+// loadSettings reads the manifest of a language's template set; a set
+// without one gets the defaults.
+func loadSettings(source TemplateSource, lang string) (TemplateSettings, error) {
+	var settings TemplateSettings
+	content, err := source.ReadAsset(lang, ManifestName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return settings, nil
+	}
+	if err != nil {
+		return settings, err
+	}
+	if err := yaml.UnmarshalWithOptions(content, &settings, yaml.Strict()); err != nil {
+		return settings, fmt.Errorf("%s: %w", ManifestName, err)
+	}
+	return settings, nil
+}
@@ -2,10 +2,15 @@ package generator
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"text/template"
 
 	"github.com/KoHorizon/ForgeDir/internal/builder"
@@ -15,19 +20,40 @@ import (
 
 // GenericGenerator uses embedded templates for boilerplate generation.
 type GenericGenerator struct {
-	lang string
-	tmpl *template.Template
-	fs   builder.FileSystem
+	lang   string
+	tmpl   *template.Template
+	fs     builder.FileSystem
+	source TemplateSource
+	assets map[string]bool // non-template files of the template set
+	slots  chan struct{}   // one per file rendered and written at once
+
+	settings TemplateSettings // from the template set's manifest
 }
 
 // NewGenericGenerator initializes a GenericGenerator for the given language.
 func NewGenericGenerator(lang string, fs builder.FileSystem) (*GenericGenerator, error) {
-	patterns := []string{filepath.Join("templates", lang, "*.tmpl")}
-	parsed, err := template.ParseFS(tmplFS, patterns...)
+	return newGenerator(lang, fs, NewEmbeddedTemplateSource(tmplFS))
+}
+
+// newGenerator parses the templates and indexes the assets of a language.
+func newGenerator(lang string, fs builder.FileSystem, source TemplateSource) (*GenericGenerator, error) {
+	parsed, err := source.ParseTemplates(lang)
 	if err != nil {
 		return nil, fmt.Errorf("parsing templates for %q: %w", lang, err)
 	}
-	return &GenericGenerator{lang: lang, tmpl: parsed, fs: fs}, nil
+	names, err := source.ListAssets(lang)
+	if err != nil {
+		return nil, fmt.Errorf("listing assets for %q: %w", lang, err)
+	}
+	assets := make(map[string]bool, len(names))
+	for _, name := range names {
+		assets[name] = true
+	}
+	settings, err := loadSettings(source, lang)
+	if err != nil {
+		return nil, fmt.Errorf("reading settings for %q: %w", lang, err)
+	}
+	return &GenericGenerator{lang: lang, tmpl: parsed, fs: fs, source: source, assets: assets, slots: make(chan struct{}, 1), settings: settings}, nil
 }
 
 // GetLanguage returns the generator's language.
@@ -36,61 +62,232 @@ func (g *GenericGenerator) GetLanguage() string {
 }
 
 // Generate renders templates into existing files under root.
-// Only iterate the files listed in your config.Structure
-func (g *GenericGenerator) Generate(cfg *config.Config, root string) error {
+// Only iterate the files listed in your config.Structure, and of those only
+// the ones whose effective language (see Config.Languages) is the generator's
+// own; the others still appear in the templates' .Files.
+func (g *GenericGenerator) Generate(ctx context.Context, cfg *config.Config, root string) error {
+	// 0. expand forEach and drop nodes whose `when` condition is false
+	cfg, err := cfg.Expand()
+	if err != nil {
+		return err
+	}
+
 	// 1. flatten your YAML tree into a list of relative file paths
-	var files []string
+	var files []fileEntry
+	var all []string
 
-	// Simple DFS with an inline stack of (nodes, basePath)
-	stack := []struct {
+	// Simple DFS with an inline stack of (nodes, basePath, language)
+	type frame struct {
 		nodes []config.StructureNode
 		base  string
-	}{{cfg.Structure, ""}}
+		lang  string
+	}
+	stack := []frame{{cfg.Structure, "", cfg.Language}}
 
 	for len(stack) > 0 {
 		// pop
-		frame := stack[len(stack)-1]
+		top := stack[len(stack)-1]
 		stack = stack[:len(stack)-1]
 
-		for _, n := range frame.nodes {
-			rel := filepath.Join(frame.base, n.Name)
+		for _, n := range top.nodes {
+			rel := filepath.Join(top.base, n.Name)
+			lang := top.lang
+			if n.Language != "" {
+				lang = n.Language
+			}
 			if n.Type == config.TypeDir {
 				// push child directory
-				stack = append(stack, struct {
-					nodes []config.StructureNode
-					base  string
-				}{n.Children, rel})
+				stack = append(stack, frame{n.Children, rel, lang})
 			} else if n.Type == config.TypeFile {
-				files = append(files, rel)
+				all = append(all, filepath.ToSlash(rel))
+				// files in other languages are left to their own generator
+				if lang == g.lang {
+					files = append(files, fileEntry{rel: rel, node: n})
+				}
 			}
 		}
 	}
 
-	// 2. render templates only for the files your spec asked for
-	for _, rel := range files {
-		target := filepath.Join(root, rel)
-		if err := g.generateFile(target, root); err != nil {
+	// slash-separated, sorted view of every file for cross-file templates,
+	// whatever its language
+	sort.Strings(all)
+
+	// 2. create every directory ahead of its files, parents first
+	seen := map[string]bool{}
+	var dirs []string
+	for _, f := range files {
+		if dir := filepath.Dir(filepath.Join(root, f.rel)); !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// Never create folders outside the project root either
+		if _, err := utils.SanitizeWritePath(root, dir); err != nil {
+			return fmt.Errorf("path safety check failed for %q: %w", dir, err)
+		}
+		if err := g.fs.CreateFolder(ctx, dir, builder.DefaultFolderPermission); err != nil {
+			return fmt.Errorf("creating folder %q: %w", dir, err)
+		}
+	}
+
+	// 3. render templates only for the files your spec asked for
+	return g.generateFiles(ctx, cfg, all, files, root)
+}
+
+// generateFiles renders and writes files with up to cap(g.slots) workers. Once a
+// file fails, the files after it are skipped while the ones before it still
+// run, so the error reported is always the one a sequential run would report,
+// whatever the scheduling. Each file takes one of g.slots while it is
+// rendered and written. The log lines of each file are held back until those
+// of the files before it are printed, so they come out in a sequential run's
+// order too.
+func (g *GenericGenerator) generateFiles(ctx context.Context, cfg *config.Config, all []string, files []fileEntry, root string) error {
+	errs := make([]error, len(files))
+	var failed atomic.Int64 // index of the first failed file so far
+	failed.Store(int64(len(files)))
+	log := newOrderedLog(builder.Log(ctx), len(files))
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < max(1, min(cap(g.slots), len(files))); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if int64(i) <= failed.Load() {
+					errs[i] = g.generateSlot(builder.WithLog(ctx, log.file(i)), cfg, all, files[i], root)
+					if errs[i] != nil {
+						lowerTo(&failed, int64(i))
+					}
+				}
+				log.done(i)
+			}
+		}()
+	}
+	for i := range files {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-// generateFile tries in order: file-specific then catch-all (_.tmpl).
-// It passes generic data into the template, not Go-specific.
-func (g *GenericGenerator) generateFile(path, root string) error {
+// generateSlot generates one file once it gets one of g.slots.
+func (g *GenericGenerator) generateSlot(ctx context.Context, cfg *config.Config, all []string, f fileEntry, root string) error {
+	select {
+	case g.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-g.slots }()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return g.generateFile(ctx, cfg, all, f.node, filepath.Join(root, f.rel), root)
+}
+
+// orderedLog collects the log lines of files generated concurrently and
+// prints them to out in file order: a file's lines are printed once it is
+// done and every file before it has been printed.
+type orderedLog struct {
+	out   io.Writer
+	mu    sync.Mutex
+	bufs  []bytes.Buffer
+	ended []bool
+	next  int // first file not printed yet
+}
+
+func newOrderedLog(out io.Writer, n int) *orderedLog {
+	return &orderedLog{out: out, bufs: make([]bytes.Buffer, n), ended: make([]bool, n)}
+}
+
+// file returns the writer collecting the lines of file i.
+func (l *orderedLog) file(i int) io.Writer {
+	return &l.bufs[i]
+}
+
+// done marks file i as done and prints what can be printed.
+func (l *orderedLog) done(i int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.ended[i] = true
+	for ; l.next < len(l.bufs) && l.ended[l.next]; l.next++ {
+		l.out.Write(l.bufs[l.next].Bytes())
+		l.bufs[l.next] = bytes.Buffer{}
+	}
+}
+
+// lowerTo sets v to n unless it already holds a smaller value.
+func lowerTo(v *atomic.Int64, n int64) {
+	for cur := v.Load(); n < cur; cur = v.Load() {
+		if v.CompareAndSwap(cur, n) {
+			return
+		}
+	}
+}
+
+// fileEntry is a file node with its path relative to the project root.
+type fileEntry struct {
+	rel  string
+	node config.StructureNode
+}
+
+// templateData is the generic data passed into every template.
+type templateData struct {
+	// Language is the configured language.
+	Language string
+	// ProjectName is the projectName from the spec.
+	ProjectName string
+	// DirName is the name of the file's directory under root (or empty).
+	DirName string
+	// FileName is the base name without extension.
+	FileName string
+	// Path is the project-relative, slash-separated path of the file.
+	Path string
+	// JavaPackage is the package derived from the path under src/<set>/java.
+	JavaPackage string
+	// IncludeGuard is a C/C++ header guard derived from ProjectName and Path.
+	IncludeGuard string
+	// Files lists every file in the spec (project-relative, slash-separated, sorted).
+	Files []string
+	// Vars holds the spec's variables.
+	Vars map[string]any
+	// Item is the forEach item the file was generated for (nil outside loops).
+	Item any
+	// Items holds the items of every enclosing forEach loop, outermost first.
+	Items []any
+}
+
+// generateFile renders the node's inline content (content, then contentFrom)
+// if it has any, else the template or asset named by the node's `template`
+// key, otherwise tries in order: file-specific template, file-specific asset,
+// extension catch-all ((default).<ext>.tmpl) then catch-all ((default).tmpl).
+// Assets are copied as they are; templates get generic data, not Go-specific.
+func (g *GenericGenerator) generateFile(ctx context.Context, cfg *config.Config, files []string, node config.StructureNode, path, root string) error {
 	name := filepath.Base(path)
 
-	// lookup order: specific then catch-all
-	tpl := g.tmpl.Lookup(name + ".tmpl")
+	tpl, err := g.inlineTemplate(node, name)
+	if err != nil {
+		return err
+	}
+	asset := ""
 	if tpl == nil {
-		tpl = g.tmpl.Lookup("(default).tmpl")
+		if tpl, asset, err = g.lookup(node, name); err != nil {
+			return err
+		}
 	}
 
-	// Prepare generic template data
-	// Language: the configured language
-	// DirName: the name of the file's directory under root (or empty)
-	// FileName: the base name without extension
 	dir := filepath.Dir(path)
 	rel, _ := filepath.Rel(root, dir)
 	d := ""
@@ -99,18 +296,27 @@ func (g *GenericGenerator) generateFile(path, root string) error {
 		parts := strings.Split(rel, string(filepath.Separator))
 		d = parts[len(parts)-1]
 	}
-	data := struct {
-		Language string
-		DirName  string
-		FileName string
-	}{
-		Language: g.lang,
-		DirName:  d,
-		FileName: strings.TrimSuffix(name, filepath.Ext(name)),
+	relPath := filepath.ToSlash(filepath.Join(rel, name))
+	data := templateData{
+		Language:     g.lang,
+		ProjectName:  cfg.ProjectName,
+		DirName:      d,
+		FileName:     strings.TrimSuffix(name, filepath.Ext(name)),
+		Path:         relPath,
+		JavaPackage:  javaPackage(filepath.ToSlash(rel)),
+		IncludeGuard: includeGuard(cfg.ProjectName, relPath),
+		Files:        files,
+		Vars:         cfg.Variables,
+		Item:         node.Item,
+		Items:        node.Items,
 	}
 
 	var content []byte
-	if tpl != nil {
+	if asset != "" {
+		if content, err = g.source.ReadAsset(g.lang, asset); err != nil {
+			return fmt.Errorf("reading asset %q: %w", asset, err)
+		}
+	} else if tpl != nil {
 		buf := &bytes.Buffer{}
 		if err := tpl.Execute(buf, data); err != nil {
 			return fmt.Errorf("executing template %q: %w", name, err)
@@ -120,35 +326,115 @@ func (g *GenericGenerator) generateFile(path, root string) error {
 		content = []byte(fmt.Sprintf("// no template for %s\n", name))
 	}
 
-	// Ensure directory exists and write file
-	dirPath := filepath.Dir(path)
-	if err := g.fs.CreateFolder(dirPath, builder.DefaultFolderPermission); err != nil {
-		return fmt.Errorf("creating folder %q: %w", dirPath, err)
+	// Never write outside the project root, whatever the rendered names were
+	if _, err := utils.SanitizeWritePath(root, path); err != nil {
+		return fmt.Errorf("path safety check failed for %q: %w", path, err)
+	}
+
+	// Write the file; Generate created its directory
+	perm, err := filePerm(node, content, g.settings.executableScripts())
+	if err != nil {
+		return fmt.Errorf("%q: %w", path, err)
 	}
-	if err := g.fs.WriteFile(path, content, builder.DefaultFilePermission); err != nil {
+	if err := g.fs.WriteFile(ctx, path, content, perm); err != nil {
 		return fmt.Errorf("writing file %q: %w", path, err)
 	}
 
 	return nil
 }
 
-// GetTemplatesForLanguage returns a list of template files for the given language
-func GetTemplatesForLanguage(language string) ([]string, error) {
-	templateDir := filepath.Join("templates", language)
+// lookup finds the template or asset for a file without inline content; both
+// are nil/empty when the template set has nothing for it.
+func (g *GenericGenerator) lookup(node config.StructureNode, name string) (*template.Template, string, error) {
+	// a template chosen in the spec must exist
+	if node.Template != "" {
+		for _, candidate := range TemplateCandidates(node.Template, name) {
+			if tpl := g.tmpl.Lookup(candidate); tpl != nil {
+				return tpl, "", nil
+			}
+			if g.assets[candidate] {
+				return nil, candidate, nil
+			}
+		}
+		return nil, "", fmt.Errorf("template %q for %q not found for %s", node.Template, name, g.lang)
+	}
 
-	entries, err := tmplFS.ReadDir(templateDir)
-	if err != nil {
-		return nil, fmt.Errorf("language '%s' not found", language)
+	// lookup order: specific, asset, per-extension, then catch-all
+	if tpl := g.tmpl.Lookup(name + ".tmpl"); tpl != nil {
+		return tpl, "", nil
+	}
+	if g.assets[name] {
+		return nil, name, nil
 	}
+	if ext := filepath.Ext(name); ext != "" {
+		if tpl := g.tmpl.Lookup("(default)" + ext + ".tmpl"); tpl != nil {
+			return tpl, "", nil
+		}
+	}
+	return g.tmpl.Lookup("(default).tmpl"), "", nil
+}
 
-	var templates []string
-	for _, entry := range entries {
-		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".tmpl") {
-			templates = append(templates, entry.Name())
+// filePerm returns the node's mode if the spec sets one. Otherwise scripts,
+// files whose content starts with a shebang, are made executable when the
+// template set's executableScripts setting is on, and other files keep the
+// mode they have.
+func filePerm(node config.StructureNode, content []byte, executableScripts bool) (builder.FilePerm, error) {
+	if node.Mode != "" {
+		perm, err := node.Mode.Perm(builder.DefaultFilePermission)
+		if err != nil {
+			return builder.KeepPerm, err
 		}
+		return builder.WithPerm(perm), nil
 	}
+	if executableScripts && bytes.HasPrefix(content, []byte("#!")) {
+		return builder.WithPerm(builder.ExecutableFilePermission), nil
+	}
+	return builder.KeepPerm, nil
+}
 
-	return templates, nil
+// TemplateCandidates returns the template names a node's `template` key may
+// refer to, in lookup order: the name as given, with ".tmpl" appended, then
+// with the file's extension and ".tmpl" appended. For user.go,
+// "rest/handler" tries "rest/handler", "rest/handler.tmpl" and "rest/handler.go.tmpl".
+func TemplateCandidates(ref, fileName string) []string {
+	ref = filepath.ToSlash(ref)
+	candidates := []string{ref}
+	if !strings.HasSuffix(ref, ".tmpl") {
+		candidates = append(candidates, ref+".tmpl")
+		if ext := filepath.Ext(fileName); ext != "" && !strings.HasSuffix(ref, ext) {
+			candidates = append(candidates, ref+ext+".tmpl")
+		}
+	}
+	return candidates
+}
+
+// inlineTemplate parses the content given in the spec itself, or returns nil
+// when the node has none. contentFrom paths are resolved by config.Load.
+func (g *GenericGenerator) inlineTemplate(node config.StructureNode, name string) (*template.Template, error) {
+	var text string
+	switch {
+	case node.Content != nil:
+		text = *node.Content
+	case node.ContentFrom != "":
+		raw, err := os.ReadFile(node.ContentFrom)
+		if err != nil {
+			return nil, fmt.Errorf("reading contentFrom for %q: %w", name, err)
+		}
+		text = string(raw)
+	default:
+		return nil, nil
+	}
+
+	tpl, err := newTemplate().New(name).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parsing inline content for %q: %w", name, err)
+	}
+	return tpl, nil
+}
+
+// GetTemplatesForLanguage returns a list of template files for the given language
+func GetTemplatesForLanguage(language string) ([]string, error) {
+	return NewEmbeddedTemplateSource(tmplFS).ListTemplates(language)
 }
 
 // CreateTemplateSource creates the appropriate template source based on templatesDir
@@ -0,0 +1,675 @@
+package generator_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/KoHorizon/ForgeDir/internal/builder"
+	"github.com/KoHorizon/ForgeDir/internal/config"
+	"github.com/KoHorizon/ForgeDir/internal/generator"
+)
+
+// memFS records written files and their permissions in memory
+type memFS struct {
+	mu    sync.Mutex
+	files map[string]string
+	perms map[string]builder.FilePerm
+}
+
+func newMemFS() *memFS {
+	return &memFS{files: map[string]string{}, perms: map[string]builder.FilePerm{}}
+}
+
+func (m *memFS) CreateFolder(ctx context.Context, path string, perm os.FileMode) error {
+	return nil
+}
+
+func (m *memFS) CreateFile(ctx context.Context, path string, perm builder.FilePerm) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.files[path]; !ok {
+		m.files[path] = ""
+		m.perms[path] = perm
+	}
+	return nil
+}
+
+func (m *memFS) WriteFile(ctx context.Context, path string, content []byte, perm builder.FilePerm) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[path] = string(content)
+	m.perms[path] = perm
+	return nil
+}
+
+func (m *memFS) CreateSymlink(ctx context.Context, target, path string) error {
+	return nil
+}
+
+func (m *memFS) SetPermission(ctx context.Context, path string, perm os.FileMode) error {
+	return nil
+}
+
+// generate runs the built-in generator for cfg.Language and returns the written files
+func generate(t *testing.T, cfg *config.Config) map[string]string {
+	t.Helper()
+	fs := newMemFS()
+	src, err := generator.CreateTemplateSource("")
+	if err != nil {
+		t.Fatalf("creating template source: %v", err)
+	}
+	gens, err := generator.NewGeneratorFactory(fs, src).CreateAvailableGenerators()
+	if err != nil {
+		t.Fatalf("creating generators: %v", err)
+	}
+	if err := generator.NewCoordinator(gens).RunBoilerplateGeneration(context.Background(), cfg, "/root"); err != nil {
+		t.Fatalf("generation failed: %v", err)
+	}
+	return fs.files
+}
+
+func TestGenerate_JavaPackageAndClassName(t *testing.T) {
+	cfg := &config.Config{
+		ProjectName: "shop",
+		Language:    "java",
+		Variables:   map[string]any{"groupId": "com.acme"},
+		Structure: []config.StructureNode{
+			{Type: config.TypeFile, Name: "pom.xml"},
+			{Type: config.TypeDir, Name: "src", Children: []config.StructureNode{
+				{Type: config.TypeDir, Name: "main", Children: []config.StructureNode{
+					{Type: config.TypeDir, Name: "java", Children: []config.StructureNode{
+						{Type: config.TypeFile, Name: "App.java"},
+						{Type: config.TypeDir, Name: "com", Children: []config.StructureNode{
+							{Type: config.TypeDir, Name: "acme", Children: []config.StructureNode{
+								{Type: config.TypeFile, Name: "order_item.java"},
+							}},
+						}},
+					}},
+				}},
+			}},
+		},
+	}
+
+	files := generate(t, cfg)
+
+	item := files[filepath.Join("/root", "src", "main", "java", "com", "acme", "order_item.java")]
+	if !strings.Contains(item, "package com.acme;") {
+		t.Errorf("expected package declaration, got:\n%s", item)
+	}
+	if !strings.Contains(item, "public class OrderItem {") {
+		t.Errorf("expected PascalCase class, got:\n%s", item)
+	}
+
+	app := files[filepath.Join("/root", "src", "main", "java", "App.java")]
+	if strings.Contains(app, "package") {
+		t.Errorf("expected no package in the source root, got:\n%s", app)
+	}
+
+	pom := files[filepath.Join("/root", "pom.xml")]
+	if !strings.Contains(pom, "<groupId>com.acme</groupId>") || !strings.Contains(pom, "<artifactId>shop</artifactId>") {
+		t.Errorf("expected pom.xml filled from variables, got:\n%s", pom)
+	}
+}
+
+func TestGenerate_CMakeListsAndIncludeGuards(t *testing.T) {
+	cfg := &config.Config{
+		ProjectName: "netkit",
+		Language:    "c",
+		Structure: []config.StructureNode{
+			{Type: config.TypeFile, Name: "CMakeLists.txt"},
+			{Type: config.TypeFile, Name: "main.c"},
+			{Type: config.TypeDir, Name: "net", Children: []config.StructureNode{
+				{Type: config.TypeFile, Name: "CMakeLists.txt"},
+				{Type: config.TypeFile, Name: "socket.h"},
+				{Type: config.TypeFile, Name: "socket.c"},
+			}},
+			{Type: config.TypeDir, Name: "util", Children: []config.StructureNode{
+				{Type: config.TypeFile, Name: "strings.c"},
+				{Type: config.TypeFile, Name: "legacy.cpp"},
+			}},
+		},
+	}
+
+	files := generate(t, cfg)
+
+	root := files[filepath.Join("/root", "CMakeLists.txt")]
+	for _, want := range []string{"project(netkit LANGUAGES C)", "add_subdirectory(net)", "    main.c\n", "    util/strings.c\n", "target_link_libraries(netkit PRIVATE net)\n"} {
+		if !strings.Contains(root, want) {
+			t.Errorf("expected top-level CMakeLists.txt to contain %q, got:\n%s", want, root)
+		}
+	}
+	if strings.Contains(root, "socket.c") {
+		t.Errorf("sources of a subdirectory with its own CMakeLists.txt must not be listed at the top level:\n%s", root)
+	}
+	if strings.Contains(root, "legacy.cpp") {
+		t.Errorf("C++ sources must not be listed in a C project:\n%s", root)
+	}
+
+	net := files[filepath.Join("/root", "net", "CMakeLists.txt")]
+	if !strings.Contains(net, "add_library(net\n    socket.c\n)") {
+		t.Errorf("expected net library, got:\n%s", net)
+	}
+
+	header := files[filepath.Join("/root", "net", "socket.h")]
+	if !strings.Contains(header, "#ifndef NETKIT_NET_SOCKET_H_") {
+		t.Errorf("expected path-derived include guard, got:\n%s", header)
+	}
+	source := files[filepath.Join("/root", "net", "socket.c")]
+	if !strings.Contains(source, `#include "socket.h"`) {
+		t.Errorf("expected source to include its header, got:\n%s", source)
+	}
+}
+
+func TestGenerate_CMakeTargetsAndGuardsAreUnique(t *testing.T) {
+	util := func() config.StructureNode {
+		return config.StructureNode{Type: config.TypeDir, Name: "util", Children: []config.StructureNode{
+			{Type: config.TypeFile, Name: "CMakeLists.txt"},
+			{Type: config.TypeFile, Name: "str.h"},
+			{Type: config.TypeFile, Name: "str.cpp"},
+		}}
+	}
+	cfg := &config.Config{
+		ProjectName: "3d-engine",
+		Language:    "cpp",
+		Structure: []config.StructureNode{
+			{Type: config.TypeFile, Name: "CMakeLists.txt"},
+			{Type: config.TypeFile, Name: "main.cpp"},
+			{Type: config.TypeFile, Name: "shim.c"},
+			{Type: config.TypeDir, Name: "src", Children: []config.StructureNode{
+				{Type: config.TypeFile, Name: "CMakeLists.txt"},
+				util(),
+			}},
+			{Type: config.TypeDir, Name: "lib", Children: []config.StructureNode{
+				{Type: config.TypeFile, Name: "CMakeLists.txt"},
+				util(),
+			}},
+		},
+	}
+
+	files := generate(t, cfg)
+
+	for dir, target := range map[string]string{"src/util": "src_util", "lib/util": "lib_util"} {
+		got := files[filepath.Join("/root", filepath.FromSlash(dir), "CMakeLists.txt")]
+		want := "add_library(" + target + "\n    str.cpp\n)\ntarget_include_directories(" + target + " PUBLIC"
+		if !strings.Contains(got, want) {
+			t.Errorf("%s: expected target %s, got:\n%s", dir, target, got)
+		}
+	}
+	if got := files[filepath.Join("/root", "src", "CMakeLists.txt")]; got != "add_subdirectory(util)\n" {
+		t.Errorf("expected src to only pull in util, got:\n%s", got)
+	}
+	// src and lib have no sources, so the executable links the libraries below them
+	root := files[filepath.Join("/root", "CMakeLists.txt")]
+	want := "add_executable(3d-engine\n    main.cpp\n)\ntarget_link_libraries(3d-engine PRIVATE lib_util src_util)\n"
+	if !strings.HasSuffix(root, want) {
+		t.Errorf("expected the executable to link lib_util and src_util, got:\n%s", root)
+	}
+
+	header := files[filepath.Join("/root", "lib", "util", "str.h")]
+	if !strings.Contains(header, "#ifndef H_3D_ENGINE_LIB_UTIL_STR_H_") {
+		t.Errorf("expected an include guard that does not start with a digit, got:\n%s", header)
+	}
+}
+
+// writeTemplates creates a custom templates directory from name -> content
+func writeTemplates(t testing.TB, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// customCoordinator builds a coordinator over a custom templates directory
+func customCoordinator(t testing.TB, templates string, fs builder.FileSystem) *generator.Coordinator {
+	t.Helper()
+	return parallelCoordinator(t, templates, fs, 1)
+}
+
+// parallelCoordinator is customCoordinator with generators writing jobs files at once
+func parallelCoordinator(t testing.TB, templates string, fs builder.FileSystem, jobs int) *generator.Coordinator {
+	t.Helper()
+	src, err := generator.CreateTemplateSource(templates)
+	if err != nil {
+		t.Fatalf("creating template source: %v", err)
+	}
+	factory := generator.NewGeneratorFactory(fs, src)
+	factory.SetJobs(jobs)
+	gens, err := factory.CreateAvailableGenerators()
+	if err != nil {
+		t.Fatalf("creating generators: %v", err)
+	}
+	return generator.NewCoordinator(gens)
+}
+
+func TestGenerate_ForEachItemInTemplate(t *testing.T) {
+	templates := writeTemplates(t, map[string]string{
+		"go/(default).tmpl": "package {{ .DirName }}\n\n// {{ .Item.name }} has {{ len .Item.fields }} fields\n",
+	})
+	fs := newMemFS()
+
+	cfg := &config.Config{
+		Language: "go",
+		Variables: map[string]any{"entities": []any{
+			map[string]any{"name": "user", "fields": []any{"id", "email"}},
+		}},
+		Structure: []config.StructureNode{
+			{Type: config.TypeDir, Name: "handlers", Children: []config.StructureNode{
+				{Type: config.TypeFile, Name: "{{ .Item.name }}_handler.go", ForEach: "vars.entities"},
+			}},
+		},
+	}
+	if err := customCoordinator(t, templates, fs).RunBoilerplateGeneration(context.Background(), cfg, "/root"); err != nil {
+		t.Fatalf("generation failed: %v", err)
+	}
+
+	got := fs.files[filepath.Join("/root", "handlers", "user_handler.go")]
+	if got != "package handlers\n\n// user has 2 fields\n" {
+		t.Errorf("unexpected content: %q", got)
+	}
+}
+
+func TestGenerate_MixedLanguagesSeeEveryFile(t *testing.T) {
+	templates := writeTemplates(t, map[string]string{
+		"c/(default).tmpl":      "c: {{ range .Files }}{{ . }} {{ end }}",
+		"python/(default).tmpl": "python",
+	})
+	fs := newMemFS()
+	cfg := &config.Config{
+		Language: "c",
+		Structure: []config.StructureNode{
+			{Type: config.TypeFile, Name: "CMakeLists.txt"},
+			{Type: config.TypeDir, Name: "tools", Language: "python", Children: []config.StructureNode{
+				{Type: config.TypeFile, Name: "gen.py"},
+			}},
+		},
+	}
+	if err := customCoordinator(t, templates, fs).RunBoilerplateGeneration(context.Background(), cfg, "/root"); err != nil {
+		t.Fatalf("generation failed: %v", err)
+	}
+
+	want := map[string]string{
+		filepath.Join("/root", "CMakeLists.txt"):  "c: CMakeLists.txt tools/gen.py ",
+		filepath.Join("/root", "tools", "gen.py"): "python",
+	}
+	if len(fs.files) != len(want) {
+		t.Fatalf("expected %d files, got %v", len(want), fs.files)
+	}
+	for path, content := range want {
+		if fs.files[path] != content {
+			t.Errorf("%s: expected %q, got %q", path, content, fs.files[path])
+		}
+	}
+}
+
+func TestGenerate_InlineContentTakesPriority(t *testing.T) {
+	snippet := filepath.Join(t.TempDir(), "editorconfig")
+	if err := os.WriteFile(snippet, []byte("indent_style = {{ .Vars.indent }}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	version := "{{ .ProjectName }} {{ .Vars.version }}\n"
+
+	cfg := &config.Config{
+		ProjectName: "svc",
+		Language:    "go",
+		Variables:   map[string]any{"version": "1.2.3", "indent": "tab"},
+		Structure: []config.StructureNode{
+			// main.go has a built-in template, inline content must still win
+			{Type: config.TypeFile, Name: "main.go", Content: &version},
+			{Type: config.TypeFile, Name: ".editorconfig", ContentFrom: snippet},
+		},
+	}
+
+	files := generate(t, cfg)
+
+	if got := files[filepath.Join("/root", "main.go")]; got != "svc 1.2.3\n" {
+		t.Errorf("expected rendered inline content, got %q", got)
+	}
+	if got := files[filepath.Join("/root", ".editorconfig")]; got != "indent_style = tab\n" {
+		t.Errorf("expected rendered contentFrom, got %q", got)
+	}
+}
+
+func TestGenerate_NodeTemplateSelection(t *testing.T) {
+	templates := writeTemplates(t, map[string]string{
+		"go/(default).tmpl":       "package {{ .DirName }}\n",
+		"go/rest/handler.go.tmpl": "// REST handler for {{ .FileName }}\n",
+		"go/grpc/handler.go.tmpl": "// gRPC handler for {{ .FileName }}\n",
+		"go/handler.go.tmpl":      "// plain handler\n",
+	})
+	fs := newMemFS()
+
+	cfg := &config.Config{
+		Language: "go",
+		Structure: []config.StructureNode{
+			{Type: config.TypeFile, Name: "user.go", Template: "rest/handler"},
+			{Type: config.TypeFile, Name: "auth.go", Template: "grpc/handler.go.tmpl"},
+			{Type: config.TypeFile, Name: "order.go", Template: "handler.go"},
+		},
+	}
+	if err := customCoordinator(t, templates, fs).RunBoilerplateGeneration(context.Background(), cfg, "/root"); err != nil {
+		t.Fatalf("generation failed: %v", err)
+	}
+
+	want := map[string]string{
+		"user.go":  "// REST handler for user\n",
+		"auth.go":  "// gRPC handler for auth\n",
+		"order.go": "// plain handler\n",
+	}
+	for name, content := range want {
+		if got := fs.files[filepath.Join("/root", name)]; got != content {
+			t.Errorf("%s: expected %q, got %q", name, content, got)
+		}
+	}
+
+	cfg.Structure = []config.StructureNode{{Type: config.TypeFile, Name: "user.go", Template: "rest/missing"}}
+	err := customCoordinator(t, templates, fs).RunBoilerplateGeneration(context.Background(), cfg, "/root")
+	if err == nil || !strings.Contains(err.Error(), `template "rest/missing"`) {
+		t.Errorf("expected missing template error, got %v", err)
+	}
+}
+
+func TestGenerate_ShebangMakesExecutable(t *testing.T) {
+	script := "#!/bin/sh\necho {{ .ProjectName }}\n"
+	plain := "not a script\n"
+	cfg := &config.Config{
+		ProjectName: "svc",
+		Language:    "go",
+		Structure: []config.StructureNode{
+			{Type: config.TypeFile, Name: "build.sh", Content: &script},
+			{Type: config.TypeFile, Name: "private.sh", Content: &script, Mode: "0700"},
+			{Type: config.TypeFile, Name: "notes.txt", Content: &plain},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		manifest string // content of the set's template-set.yaml, none if empty
+		script   builder.FilePerm
+	}{
+		{name: "no manifest", script: builder.WithPerm(0755)},
+		{name: "enabled", manifest: "executableScripts: true\n", script: builder.WithPerm(0755)},
+		{name: "disabled", manifest: "executableScripts: false\n", script: builder.KeepPerm},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := map[string]string{"go/(default).tmpl": ""}
+			if tt.manifest != "" {
+				files["go/"+generator.ManifestName] = tt.manifest
+			}
+			fs := newMemFS()
+			if err := customCoordinator(t, writeTemplates(t, files), fs).RunBoilerplateGeneration(context.Background(), cfg, "/root"); err != nil {
+				t.Fatalf("generation failed: %v", err)
+			}
+
+			// other files keep whatever mode they have
+			want := map[string]builder.FilePerm{"build.sh": tt.script, "private.sh": builder.WithPerm(0700), "notes.txt": builder.KeepPerm}
+			for name, mode := range want {
+				if got := fs.perms[filepath.Join("/root", name)]; got != mode {
+					t.Errorf("%s: expected mode %v, got %v", name, mode, got)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerate_TemplateSetManifest(t *testing.T) {
+	templates := writeTemplates(t, map[string]string{
+		"go/(default).tmpl":            "",
+		"go/" + generator.ManifestName: "executableScripts: false\n",
+	})
+	src, err := generator.CreateTemplateSource(templates)
+	if err != nil {
+		t.Fatalf("creating template source: %v", err)
+	}
+	assets, err := src.ListAssets("go")
+	if err != nil {
+		t.Fatalf("listing assets: %v", err)
+	}
+	if len(assets) != 0 {
+		t.Errorf("expected the manifest not to be an asset, got %v", assets)
+	}
+
+	templates = writeTemplates(t, map[string]string{
+		"go/(default).tmpl":            "",
+		"go/" + generator.ManifestName: "executableScript: false\n",
+	})
+	if src, err = generator.CreateTemplateSource(templates); err != nil {
+		t.Fatalf("creating template source: %v", err)
+	}
+	_, err = generator.NewGeneratorFactory(newMemFS(), src).CreateAvailableGenerators()
+	if err == nil || !strings.Contains(err.Error(), generator.ManifestName) {
+		t.Errorf("expected an error naming the manifest, got %v", err)
+	}
+}
+
+func TestGenerate_StopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	fs := newMemFS()
+	cfg := &config.Config{
+		ProjectName: "svc",
+		Language:    "go",
+		Structure:   []config.StructureNode{{Type: config.TypeFile, Name: "main.go"}},
+	}
+	err := customCoordinator(t, writeTemplates(t, map[string]string{"go/(default).tmpl": ""}), fs).RunBoilerplateGeneration(ctx, cfg, "/root")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if len(fs.files) != 0 {
+		t.Errorf("expected nothing to be written, got %v", fs.files)
+	}
+}
+
+func TestGenerate_TemplateSetAssets(t *testing.T) {
+	icon := "\x00\x01{{ .NotRendered }}\xff"
+	templates := writeTemplates(t, map[string]string{
+		"web/(default).tmpl":  "rendered {{ .FileName }}\n",
+		"web/favicon.ico":     icon,
+		"web/assets/logo.svg": "<svg>{{ raw }}</svg>",
+		"web/gradlew":         "#!/bin/sh\n{{ not a template }}\n",
+	})
+	fs := newMemFS()
+
+	cfg := &config.Config{
+		Language: "web",
+		Structure: []config.StructureNode{
+			{Type: config.TypeFile, Name: "favicon.ico"},
+			{Type: config.TypeFile, Name: "gradlew"},
+			{Type: config.TypeFile, Name: "brand.svg", Template: "assets/logo.svg"},
+			{Type: config.TypeFile, Name: "index.html"},
+		},
+	}
+	if err := customCoordinator(t, templates, fs).RunBoilerplateGeneration(context.Background(), cfg, "/root"); err != nil {
+		t.Fatalf("generation failed: %v", err)
+	}
+
+	want := map[string]string{
+		"favicon.ico": icon,
+		"gradlew":     "#!/bin/sh\n{{ not a template }}\n",
+		"brand.svg":   "<svg>{{ raw }}</svg>",
+		"index.html":  "rendered index\n",
+	}
+	for name, content := range want {
+		if got := fs.files[filepath.Join("/root", name)]; got != content {
+			t.Errorf("%s: expected %q, got %q", name, content, got)
+		}
+	}
+	if got := fs.perms[filepath.Join("/root", "gradlew")]; got != builder.WithPerm(0755) {
+		t.Errorf("expected gradlew asset to be executable, got %v", got)
+	}
+}
+
+// sdkSpec is a spec of dirs directories of files files each, in the shape of
+// a generated SDK
+func sdkSpec(dirs, files int) *config.Config {
+	cfg := &config.Config{ProjectName: "sdk", Language: "go"}
+	for d := 0; d < dirs; d++ {
+		dir := config.StructureNode{Type: config.TypeDir, Name: fmt.Sprintf("service%04d", d)}
+		for f := 0; f < files; f++ {
+			dir.Children = append(dir.Children, config.StructureNode{Type: config.TypeFile, Name: fmt.Sprintf("model%03d.go", f)})
+		}
+		cfg.Structure = append(cfg.Structure, dir)
+	}
+	return cfg
+}
+
+const sdkTemplate = "// Code generated for {{ .ProjectName }}.\n\npackage {{ .DirName }}\n\ntype {{ className .FileName }} struct{}\n"
+
+func TestGenerate_ParallelMatchesSequential(t *testing.T) {
+	templates := writeTemplates(t, map[string]string{"go/(default).go.tmpl": sdkTemplate})
+	cfg := sdkSpec(20, 20)
+
+	sequential := newMemFS()
+	if err := parallelCoordinator(t, templates, sequential, 1).RunBoilerplateGeneration(context.Background(), cfg, "/root"); err != nil {
+		t.Fatalf("sequential generation failed: %v", err)
+	}
+	parallel := newMemFS()
+	if err := parallelCoordinator(t, templates, parallel, 8).RunBoilerplateGeneration(context.Background(), cfg, "/root"); err != nil {
+		t.Fatalf("parallel generation failed: %v", err)
+	}
+
+	if len(sequential.files) != 400 || len(parallel.files) != len(sequential.files) {
+		t.Fatalf("expected 400 files each, got %d and %d", len(sequential.files), len(parallel.files))
+	}
+	for path, content := range sequential.files {
+		if parallel.files[path] != content {
+			t.Errorf("%s differs: %q vs %q", path, content, parallel.files[path])
+		}
+	}
+}
+
+func TestGenerate_ParallelReportsFirstError(t *testing.T) {
+	templates := writeTemplates(t, map[string]string{"go/(default).go.tmpl": sdkTemplate})
+	broken := "{{ .Oops"
+	cfg := sdkSpec(10, 10)
+	cfg.Structure[3].Children[7].Content = &broken
+	cfg.Structure[8].Children[1].Content = &broken
+
+	want := parallelCoordinator(t, templates, newMemFS(), 1).RunBoilerplateGeneration(context.Background(), cfg, "/root")
+	if want == nil {
+		t.Fatal("expected the sequential run to fail")
+	}
+	for run := 0; run < 20; run++ {
+		err := parallelCoordinator(t, templates, newMemFS(), 8).RunBoilerplateGeneration(context.Background(), cfg, "/root")
+		if err == nil || err.Error() != want.Error() {
+			t.Fatalf("run %d: expected the sequential run's error %q, got %v", run, want, err)
+		}
+	}
+}
+
+// busyFS is a memFS that counts the files being written at the same time
+type busyFS struct {
+	*memFS
+	writing, most atomic.Int64
+}
+
+func (b *busyFS) WriteFile(ctx context.Context, path string, content []byte, perm builder.FilePerm) error {
+	n := b.writing.Add(1)
+	defer b.writing.Add(-1)
+	for cur := b.most.Load(); n > cur && !b.most.CompareAndSwap(cur, n); cur = b.most.Load() {
+	}
+	time.Sleep(time.Millisecond)
+	return b.memFS.WriteFile(ctx, path, content, perm)
+}
+
+func TestGenerate_JobsBoundTheWholeRun(t *testing.T) {
+	templates := writeTemplates(t, map[string]string{"go/(default).go.tmpl": sdkTemplate})
+	fs := &busyFS{memFS: newMemFS()}
+	coord := parallelCoordinator(t, templates, fs, 2)
+
+	// projects sharing the generators run at the same time
+	var wg sync.WaitGroup
+	errs := make([]error, 4)
+	for p := range errs {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			errs[p] = coord.RunBoilerplateGeneration(context.Background(), sdkSpec(4, 5), fmt.Sprintf("/root/p%d", p))
+		}(p)
+	}
+	wg.Wait()
+	for p, err := range errs {
+		if err != nil {
+			t.Fatalf("project %d: %v", p, err)
+		}
+	}
+
+	if len(fs.files) != 80 {
+		t.Fatalf("expected 80 files, got %d", len(fs.files))
+	}
+	if most := fs.most.Load(); most > 2 {
+		t.Errorf("expected at most 2 files written at once across projects, got %d", most)
+	}
+}
+
+func TestGenerate_ParallelLogsInOrder(t *testing.T) {
+	templates := writeTemplates(t, map[string]string{"go/(default).go.tmpl": sdkTemplate})
+	cfg := sdkSpec(5, 10)
+
+	// run logs the lines the file system prints, with paths relative to the output
+	run := func(jobs int) string {
+		root := t.TempDir()
+		var log bytes.Buffer
+		ctx := builder.WithLog(context.Background(), &log)
+		if err := parallelCoordinator(t, templates, builder.NewOSFileSystem(), jobs).RunBoilerplateGeneration(ctx, cfg, root); err != nil {
+			t.Fatalf("generation with %d jobs failed: %v", jobs, err)
+		}
+		return strings.ReplaceAll(log.String(), root, "")
+	}
+
+	want := run(1)
+	if n := strings.Count(want, "Wrote file: "); n != 50 {
+		t.Fatalf("expected 50 files logged, got %d:\n%s", n, want)
+	}
+	for i := 0; i < 10; i++ {
+		if got := run(8); got != want {
+			t.Fatalf("run %d: expected the sequential log\n%s\ngot\n%s", i, want, got)
+		}
+	}
+}
+
+// BenchmarkGenerate renders a synthetic 50k-node spec (1,000 directories of
+// 49 files) to disk, sequentially and with 8 workers.
+func BenchmarkGenerate(b *testing.B) {
+	templates := writeTemplates(b, map[string]string{"go/(default).go.tmpl": sdkTemplate})
+	cfg := sdkSpec(1000, 49)
+
+	// the file system logs every file it writes
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer devNull.Close()
+	stdout := os.Stdout
+	os.Stdout = devNull
+	defer func() { os.Stdout = stdout }()
+
+	for _, jobs := range []int{1, 8} {
+		b.Run(fmt.Sprintf("jobs=%d", jobs), func(b *testing.B) {
+			coord := parallelCoordinator(b, templates, builder.NewOSFileSystem(), jobs)
+			for i := 0; i < b.N; i++ {
+				if err := coord.RunBoilerplateGeneration(context.Background(), cfg, b.TempDir()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
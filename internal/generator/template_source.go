@@ -3,7 +3,9 @@ package generator
 import (
 	"embed"
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"text/template"
@@ -17,6 +19,10 @@ type TemplateSource interface {
 	ListLanguages() ([]string, error)
 	// ListTemplates returns template files for a language
 	ListTemplates(language string) ([]string, error)
+	// ListAssets returns the non-template files for a language, copied verbatim
+	ListAssets(language string) ([]string, error)
+	// ReadAsset returns the content of an asset listed by ListAssets
+	ReadAsset(language, name string) ([]byte, error)
 }
 
 // EmbeddedTemplateSource uses embedded templates
@@ -29,8 +35,11 @@ func NewEmbeddedTemplateSource(fs embed.FS) *EmbeddedTemplateSource {
 }
 
 func (e *EmbeddedTemplateSource) ParseTemplates(language string) (*template.Template, error) {
-	patterns := []string{filepath.Join("templates", language, "*.tmpl")}
-	return template.ParseFS(e.fs, patterns...)
+	langFS, err := fs.Sub(e.fs, path.Join("templates", language))
+	if err != nil {
+		return nil, err
+	}
+	return parseTemplateDir(langFS)
 }
 
 func (e *EmbeddedTemplateSource) ListLanguages() ([]string, error) {
@@ -49,19 +58,26 @@ func (e *EmbeddedTemplateSource) ListLanguages() ([]string, error) {
 }
 
 func (e *EmbeddedTemplateSource) ListTemplates(language string) ([]string, error) {
-	templateDir := filepath.Join("templates", language)
-	entries, err := e.fs.ReadDir(templateDir)
-	if err != nil {
+	if _, err := e.fs.ReadDir(path.Join("templates", language)); err != nil {
 		return nil, fmt.Errorf("language '%s' not found", language)
 	}
+	langFS, err := fs.Sub(e.fs, path.Join("templates", language))
+	if err != nil {
+		return nil, err
+	}
+	return listTemplateDir(langFS)
+}
 
-	var templates []string
-	for _, entry := range entries {
-		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".tmpl") {
-			templates = append(templates, entry.Name())
-		}
+func (e *EmbeddedTemplateSource) ListAssets(language string) ([]string, error) {
+	langFS, err := fs.Sub(e.fs, path.Join("templates", language))
+	if err != nil {
+		return nil, err
 	}
-	return templates, nil
+	return listAssetDir(langFS)
+}
+
+func (e *EmbeddedTemplateSource) ReadAsset(language, name string) ([]byte, error) {
+	return e.fs.ReadFile(path.Join("templates", language, name))
 }
 
 // FileSystemTemplateSource uses filesystem templates
@@ -82,8 +98,7 @@ func (f *FileSystemTemplateSource) ParseTemplates(language string) (*template.Te
 	}
 
 	// Parse all .tmpl files in the language directory
-	pattern := filepath.Join(langDir, "*.tmpl")
-	return template.ParseGlob(pattern)
+	return parseTemplateDir(os.DirFS(langDir))
 }
 
 func (f *FileSystemTemplateSource) ListLanguages() ([]string, error) {
@@ -104,16 +119,71 @@ func (f *FileSystemTemplateSource) ListLanguages() ([]string, error) {
 func (f *FileSystemTemplateSource) ListTemplates(language string) ([]string, error) {
 	langDir := filepath.Join(f.baseDir, language)
 
-	entries, err := os.ReadDir(langDir)
-	if err != nil {
+	if _, err := os.ReadDir(langDir); err != nil {
 		return nil, fmt.Errorf("language '%s' not found in %s", language, f.baseDir)
 	}
+	return listTemplateDir(os.DirFS(langDir))
+}
 
-	var templates []string
-	for _, entry := range entries {
-		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".tmpl") {
-			templates = append(templates, entry.Name())
+func (f *FileSystemTemplateSource) ListAssets(language string) ([]string, error) {
+	langDir := filepath.Join(f.baseDir, language)
+
+	if _, err := os.ReadDir(langDir); err != nil {
+		return nil, fmt.Errorf("language '%s' not found in %s", language, f.baseDir)
+	}
+	return listAssetDir(os.DirFS(langDir))
+}
+
+func (f *FileSystemTemplateSource) ReadAsset(language, name string) ([]byte, error) {
+	return fs.ReadFile(os.DirFS(filepath.Join(f.baseDir, language)), name)
+}
+
+// parseTemplateDir parses every .tmpl file below a language directory. Each
+// template is named by its slash-separated path relative to that directory,
+// so "handler.go.tmpl" and "rest/handler.go.tmpl" can coexist.
+func parseTemplateDir(fsys fs.FS) (*template.Template, error) {
+	names, err := listTemplateDir(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	root := newTemplate()
+	for _, name := range names {
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := root.New(name).Parse(string(content)); err != nil {
+			return nil, fmt.Errorf("parsing template %s: %w", name, err)
 		}
 	}
-	return templates, nil
+	return root, nil
+}
+
+// listTemplateDir returns the relative paths of every .tmpl file below a language directory.
+func listTemplateDir(fsys fs.FS) ([]string, error) {
+	return walkTemplateDir(fsys, true)
+}
+
+// listAssetDir returns the relative paths of every other file below a
+// language directory except the manifest. Assets are copied into projects
+// without rendering.
+func listAssetDir(fsys fs.FS) ([]string, error) {
+	return walkTemplateDir(fsys, false)
+}
+
+// walkTemplateDir lists the files below a language directory that
+// are templates (tmpl) or assets (!tmpl).
+func walkTemplateDir(fsys fs.FS, tmpl bool) ([]string, error) {
+	var names []string
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && p != ManifestName && strings.HasSuffix(p, ".tmpl") == tmpl {
+			names = append(names, p)
+		}
+		return nil
+	})
+	return names, err
 }
@@ -0,0 +1,251 @@
+// Package importer turns layouts sketched outside ForgeDir (`tree` output,
+// `find` output or a plain list of paths) into a spec structure.
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/KoHorizon/ForgeDir/internal/config"
+)
+
+// Input formats understood by Import.
+const (
+	FormatTree  = "tree"
+	FormatFind  = "find"
+	FormatPaths = "paths"
+)
+
+// Formats lists the input formats, for help and error messages.
+var Formats = []string{FormatTree, FormatFind, FormatPaths}
+
+// Result is an imported layout. Root is the project directory named by the
+// listing (the first line of `tree myproj` or `find myproj`), if any.
+type Result struct {
+	Root      string
+	Structure []config.StructureNode
+}
+
+// entry is one path of the listing; dir is true when the listing itself says
+// so (a trailing slash, as printed by `tree -F`).
+type entry struct {
+	path string
+	dir  bool
+}
+
+// Import parses a listing in the given format. Whether an entry is a file or
+// a directory is decided by a trailing slash, then by whether other entries
+// are below it, then by its name (see isFileName).
+func Import(r io.Reader, format string) (*Result, error) {
+	var (
+		root    string
+		entries []entry
+		err     error
+	)
+	switch format {
+	case FormatTree:
+		root, entries, err = parseTree(r)
+	case FormatFind:
+		root, entries, err = parseFind(r)
+	case FormatPaths:
+		entries, err = parsePaths(r)
+	default:
+		return nil, fmt.Errorf("unknown format %q (available: %s)", format, strings.Join(Formats, ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no paths found in %s input", format)
+	}
+
+	structure, err := config.PathNodes(classify(entries))
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Root: root, Structure: structure}, nil
+}
+
+// treeLine matches a `tree` entry: the indentation, the connector and the name.
+// Both the Unicode (├── └──) and the --charset=ascii (|-- `--) forms are
+// accepted; recent versions of tree pad with non-breaking spaces.
+var treeLine = regexp.MustCompile(`^((?:[│|][ \t\x{00a0}]{3}|[ \t\x{00a0}]{4})*)(?:├──|└──|\|--|` + "`" + `--|\+--)[ \t\x{00a0}](.*)$`)
+
+// treeSummary matches the "3 directories, 5 files" line tree ends with.
+var treeSummary = regexp.MustCompile(`^\d+ director(y|ies)(, \d+ files?)?$`)
+
+// parseTree reads `tree` output. The first line without a connector names the
+// root; every level of indentation is four columns wide.
+func parseTree(r io.Reader) (string, []entry, error) {
+	var (
+		root    string
+		entries []entry
+		parents []string // directory names of the current branch
+	)
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || treeSummary.MatchString(strings.TrimSpace(line)) {
+			continue
+		}
+
+		m := treeLine.FindStringSubmatch(line)
+		if m == nil {
+			if root == "" && len(entries) == 0 {
+				root = strings.TrimSuffix(strings.TrimSpace(line), "/")
+				continue
+			}
+			return "", nil, fmt.Errorf("line %d: not a tree entry: %q", n, line)
+		}
+
+		depth := len([]rune(m[1])) / 4
+		if depth > len(parents) {
+			return "", nil, fmt.Errorf("line %d: %q is indented deeper than its parent", n, line)
+		}
+		name, isDir := cleanName(m[2])
+		if name == "" {
+			return "", nil, fmt.Errorf("line %d: empty name", n)
+		}
+
+		parents = append(parents[:depth], name)
+		entries = append(entries, entry{path: path.Join(parents...), dir: isDir})
+	}
+	if err := scanner.Err(); err != nil {
+		return "", nil, err
+	}
+	if root == "." {
+		root = ""
+	}
+	return root, entries, nil
+}
+
+// parseFind reads `find` output. When every path lies below the first one
+// ("." or the directory find was started in), that one is the root and is
+// stripped from the others.
+func parseFind(r io.Reader) (string, []entry, error) {
+	lines, err := readLines(r)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(lines) == 0 {
+		return "", nil, nil
+	}
+
+	root := strings.TrimSuffix(lines[0], "/")
+	for _, l := range lines[1:] {
+		if !strings.HasPrefix(l, root+"/") {
+			root = ""
+			break
+		}
+	}
+
+	var entries []entry
+	for _, l := range lines {
+		if root != "" {
+			if l == root || l == root+"/" {
+				continue
+			}
+			l = strings.TrimPrefix(l, root+"/")
+		}
+		name, isDir := cleanName(strings.TrimPrefix(l, "./"))
+		entries = append(entries, entry{path: name, dir: isDir})
+	}
+	if root == "" || root == "." {
+		return "", entries, nil
+	}
+	return path.Base(root), entries, nil
+}
+
+// parsePaths reads one path per line; blank lines and # comments are skipped.
+func parsePaths(r io.Reader) ([]entry, error) {
+	lines, err := readLines(r)
+	if err != nil {
+		return nil, err
+	}
+	var entries []entry
+	for _, l := range lines {
+		if strings.HasPrefix(l, "#") {
+			continue
+		}
+		name, isDir := cleanName(strings.TrimPrefix(l, "./"))
+		entries = append(entries, entry{path: name, dir: isDir})
+	}
+	return entries, nil
+}
+
+// readLines returns the trimmed, non-empty lines of r with slashes normalized.
+func readLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if l := strings.TrimSpace(scanner.Text()); l != "" {
+			lines = append(lines, strings.ReplaceAll(l, "\\", "/"))
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// cleanName drops what tree and ls-style listings append to names (a
+// " -> target" for symlinks, the -F markers) and reports a trailing slash.
+func cleanName(name string) (string, bool) {
+	if i := strings.Index(name, " -> "); i >= 0 {
+		name = name[:i]
+	}
+	name = strings.TrimSpace(strings.TrimRight(strings.TrimSpace(name), "*@=|"))
+	isDir := strings.HasSuffix(name, "/")
+	return strings.TrimRight(name, "/"), isDir
+}
+
+// classify turns entries into config.PathNodes paths, with a trailing slash
+// on every directory.
+func classify(entries []entry) []string {
+	parents := map[string]bool{}
+	for _, e := range entries {
+		for dir := path.Dir(e.path); dir != "." && dir != "/"; dir = path.Dir(dir) {
+			parents[dir] = true
+		}
+	}
+
+	paths := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.dir || parents[e.path] || !isFileName(path.Base(e.path)) {
+			paths = append(paths, e.path+"/")
+		} else {
+			paths = append(paths, e.path)
+		}
+	}
+	return paths
+}
+
+// wellKnownFiles are common file names without an extension.
+var wellKnownFiles = map[string]bool{
+	"makefile": true, "dockerfile": true, "containerfile": true, "jenkinsfile": true,
+	"procfile": true, "gemfile": true, "rakefile": true, "vagrantfile": true,
+	"brewfile": true, "justfile": true, "license": true, "licence": true,
+	"readme": true, "changelog": true, "authors": true, "contributors": true,
+	"notice": true, "codeowners": true, "version": true, "gradlew": true, "mvnw": true,
+}
+
+// wellKnownDotDirs are common directory names starting with a dot.
+var wellKnownDotDirs = map[string]bool{
+	".github": true, ".gitlab": true, ".vscode": true, ".idea": true,
+	".devcontainer": true, ".circleci": true, ".husky": true, ".config": true,
+}
+
+// isFileName guesses whether a childless entry is a file: it has an
+// extension, is a dotfile, or is a well-known name such as Makefile.
+// Anything else (bin, docs, internal) is taken to be an empty directory.
+func isFileName(name string) bool {
+	if strings.HasPrefix(name, ".") {
+		return !wellKnownDotDirs[strings.ToLower(name)]
+	}
+	if ext := path.Ext(name); ext != "" && ext != name {
+		return true
+	}
+	return wellKnownFiles[strings.ToLower(name)]
+}
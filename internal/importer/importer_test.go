@@ -0,0 +1,186 @@
+package importer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/KoHorizon/ForgeDir/internal/config"
+	"github.com/KoHorizon/ForgeDir/internal/importer"
+)
+
+// flatten lists a tree as "d path" / "f path" lines in order
+func flatten(nodes []config.StructureNode, base string) []string {
+	var out []string
+	for _, n := range nodes {
+		p := base + n.Name
+		out = append(out, n.Type[:1]+" "+p)
+		out = append(out, flatten(n.Children, p+"/")...)
+	}
+	return out
+}
+
+const wantLayout = `d cmd
+d cmd/server
+f cmd/server/main.go
+d internal
+d internal/handlers
+f internal/handlers/user.go
+d internal/models
+d .github
+d .github/workflows
+f .github/workflows/ci.yml
+f go.mod
+f Makefile
+f .gitignore`
+
+func TestImport(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		input    string
+		wantRoot string
+	}{
+		{
+			name:     "tree",
+			format:   importer.FormatTree,
+			wantRoot: "my-api",
+			input: `my-api
+├── cmd
+│   └── server
+│       └── main.go
+├── internal
+│   ├── handlers
+│   │   └── user.go
+│   └── models
+├── .github
+│   └── workflows
+│       └── ci.yml
+├── go.mod
+├── Makefile
+└── .gitignore
+
+7 directories, 6 files
+`,
+		},
+		{
+			name:   "tree with non-breaking spaces and -F markers",
+			format: importer.FormatTree,
+			input: ".\n" +
+				"├──\u00a0cmd/\n" +
+				"│\u00a0\u00a0 └──\u00a0server/\n" +
+				"│\u00a0\u00a0     └──\u00a0main.go\n" +
+				"├──\u00a0internal/\n" +
+				"│\u00a0\u00a0 ├──\u00a0handlers/\n" +
+				"│\u00a0\u00a0 │\u00a0\u00a0 └──\u00a0user.go\n" +
+				"│\u00a0\u00a0 └──\u00a0models/\n" +
+				"├──\u00a0.github/\n" +
+				"│\u00a0\u00a0 └──\u00a0workflows/\n" +
+				"│\u00a0\u00a0     └──\u00a0ci.yml\n" +
+				"├──\u00a0go.mod\n" +
+				"├──\u00a0Makefile*\n" +
+				"└──\u00a0.gitignore\n",
+		},
+		{
+			name:     "ascii tree",
+			format:   importer.FormatTree,
+			wantRoot: "my-api",
+			input: "my-api/\n" +
+				"|-- cmd\n" +
+				"|   `-- server\n" +
+				"|       `-- main.go\n" +
+				"|-- internal\n" +
+				"|   |-- handlers\n" +
+				"|   |   `-- user.go\n" +
+				"|   `-- models\n" +
+				"|-- .github\n" +
+				"|   `-- workflows\n" +
+				"|       `-- ci.yml\n" +
+				"|-- go.mod\n" +
+				"|-- Makefile\n" +
+				"`-- .gitignore\n",
+		},
+		{
+			name:     "find",
+			format:   importer.FormatFind,
+			wantRoot: "my-api",
+			input: `../src/my-api
+../src/my-api/cmd
+../src/my-api/cmd/server
+../src/my-api/cmd/server/main.go
+../src/my-api/internal
+../src/my-api/internal/handlers
+../src/my-api/internal/handlers/user.go
+../src/my-api/internal/models
+../src/my-api/.github/workflows/ci.yml
+../src/my-api/go.mod
+../src/my-api/Makefile
+../src/my-api/.gitignore
+`,
+		},
+		{
+			name:   "find from the current directory",
+			format: importer.FormatFind,
+			input: `.
+./cmd/server/main.go
+./internal/handlers/user.go
+./internal/models
+./.github/workflows/ci.yml
+./go.mod
+./Makefile
+./.gitignore
+`,
+		},
+		{
+			name:   "paths",
+			format: importer.FormatPaths,
+			input: `# API layout
+cmd/server/main.go
+internal/handlers/user.go
+internal/models/
+
+.github/workflows/ci.yml
+go.mod
+Makefile
+.gitignore
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := importer.Import(strings.NewReader(tt.input), tt.format)
+			if err != nil {
+				t.Fatalf("Import returned unexpected error: %v", err)
+			}
+			if result.Root != tt.wantRoot {
+				t.Errorf("expected root %q, got %q", tt.wantRoot, result.Root)
+			}
+			if got := strings.Join(flatten(result.Structure, ""), "\n"); got != wantLayout {
+				t.Errorf("unexpected layout:\n%s\nwant:\n%s", got, wantLayout)
+			}
+		})
+	}
+}
+
+func TestImport_Errors(t *testing.T) {
+	tests := []struct {
+		name          string
+		format        string
+		input         string
+		errorContains string
+	}{
+		{name: "unknown format", format: "ls", input: "a\n", errorContains: "unknown format"},
+		{name: "empty", format: importer.FormatPaths, input: "\n# nothing\n", errorContains: "no paths found"},
+		{name: "tree skipping a level", format: importer.FormatTree, input: ".\n│   └── main.go\n", errorContains: "indented deeper than its parent"},
+		{name: "garbage in tree", format: importer.FormatTree, input: ".\n├── a\nnot a tree line\n", errorContains: "not a tree entry"},
+		{name: "absolute path", format: importer.FormatPaths, input: "/etc/passwd\n", errorContains: "absolute paths are not allowed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := importer.Import(strings.NewReader(tt.input), tt.format)
+			if err == nil || !strings.Contains(err.Error(), tt.errorContains) {
+				t.Errorf("expected error containing %q, got %v", tt.errorContains, err)
+			}
+		})
+	}
+}
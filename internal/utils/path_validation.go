@@ -1,19 +1,28 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
 	"path/filepath"
 	"strings"
 )
 
-// ValidatePath ensures a path component is safe for use within a project directory.
-// It prevents path traversal attacks by rejecting paths that:
+// ValidatePath ensures a path component is safe for use within a project directory,
+// under the Portable profile. Every profile rejects paths that:
 // - Are empty or just whitespace
 // - Are absolute paths (including Windows drive paths)
 // - Contain ".." segments
-// - Contain invalid characters for filenames
 // - Contain path separators (should be handled by structure)
+// Portable also rejects invalid characters for filenames, Windows reserved
+// names, trailing dots and spaces, control characters and overlong names.
 func ValidatePath(name string) error {
+	return Portable.ValidateName(name)
+}
+
+// validateComponent applies the safety checks shared by every profile.
+func validateComponent(name string) error {
 	// Remove leading/trailing whitespace
 	name = strings.TrimSpace(name)
 
@@ -44,34 +53,96 @@ func ValidatePath(name string) error {
 		return fmt.Errorf("path separators are not allowed in names: %s", name)
 	}
 
-	// Check for invalid filename characters (Windows + common problematic chars)
-	invalidChars := []string{"<", ">", ":", "\"", "|", "?", "*"}
-	for _, char := range invalidChars {
-		if strings.Contains(name, char) {
-			return fmt.Errorf("invalid character '%s' in path name: %s", char, name)
-		}
-	}
+	return nil
+}
+
+// ResolveLinkTarget resolves the target of a symlink at linkPath the way the
+// OS will, relative to the link's directory, and ensures it stays inside the
+// project root under the same rules as SanitizePath. Absolute targets are
+// rejected, as is a link pointing at itself. It returns the absolute target.
+func ResolveLinkTarget(projectRoot, linkPath, target string) (string, error) {
+	return linkTarget(projectRoot, linkPath, target, SanitizePath)
+}
+
+// LexicalLinkTarget is ResolveLinkTarget without looking at the disk: the
+// target is only checked lexically, so symlinks already on disk are ignored.
+func LexicalLinkTarget(projectRoot, linkPath, target string) (string, error) {
+	return linkTarget(projectRoot, linkPath, target, lexicalPath)
+}
 
-	// Check for reserved names (Windows reserved filenames)
-	reservedNames := []string{
-		"CON", "PRN", "AUX", "NUL",
-		"COM1", "COM2", "COM3", "COM4", "COM5", "COM6", "COM7", "COM8", "COM9",
-		"LPT1", "LPT2", "LPT3", "LPT4", "LPT5", "LPT6", "LPT7", "LPT8", "LPT9",
+func linkTarget(projectRoot, linkPath, target string, sanitize func(projectRoot, targetPath string) (string, error)) (string, error) {
+	if strings.TrimSpace(target) == "" {
+		return "", fmt.Errorf("symlink target cannot be empty")
+	}
+	if filepath.IsAbs(target) || strings.HasPrefix(target, "/") || strings.HasPrefix(target, "\\") || len(target) >= 2 && target[1] == ':' {
+		return "", fmt.Errorf("absolute symlink targets are not allowed: %s", target)
 	}
 
-	upperName := strings.ToUpper(name)
-	for _, reserved := range reservedNames {
-		if upperName == reserved {
-			return fmt.Errorf("reserved filename not allowed: %s", name)
-		}
+	resolved := filepath.Join(filepath.Dir(linkPath), filepath.FromSlash(target))
+	safe, err := sanitize(projectRoot, resolved)
+	if err != nil {
+		return "", fmt.Errorf("symlink target outside project root not allowed: %s", target)
 	}
 
-	return nil
+	absLink, err := filepath.Abs(linkPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute link path: %w", err)
+	}
+	if safe == absLink {
+		return "", fmt.Errorf("symlink cannot point to itself: %s", target)
+	}
+	return safe, nil
 }
 
 // SanitizePath creates a safe version of a path by cleaning it and ensuring it stays within bounds.
-// This is used as a secondary safety measure after validation.
+// This is used as a secondary safety measure after validation. Besides the
+// lexical check, the directories above the path are resolved through the
+// symlinks already on disk, so a symlink (say internal -> /etc) cannot lead a
+// write out of the project root. A symlink at the path itself is followed
+// when its target exists; a broken one is accepted, since the path can still
+// replace it (see SanitizeWritePath for paths that are written through).
 func SanitizePath(projectRoot, targetPath string) (string, error) {
+	absTarget, err := lexicalPath(projectRoot, targetPath)
+	if err != nil {
+		return "", err
+	}
+
+	// Follow the symlinks already on disk, from the root down
+	realRoot, err := resolveExisting(mustAbs(projectRoot))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve project root: %w", err)
+	}
+	realTarget, err := resolveLeaf(absTarget)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", targetPath, err)
+	}
+	relPath, err := filepath.Rel(realRoot, realTarget)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path escapes project root through a symlink: %s", targetPath)
+	}
+
+	return absTarget, nil
+}
+
+// SanitizeWritePath is SanitizePath for a path that is written through, as a
+// file or a directory: a broken symlink there is refused, since writing
+// through it would create its target.
+func SanitizeWritePath(projectRoot, targetPath string) (string, error) {
+	absTarget, err := SanitizePath(projectRoot, targetPath)
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Lstat(absTarget); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		if _, err := os.Stat(absTarget); errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("broken symlink not allowed: %s", targetPath)
+		}
+	}
+	return absTarget, nil
+}
+
+// lexicalPath cleans targetPath and ensures it stays within projectRoot,
+// without looking at the disk. It returns the absolute path.
+func lexicalPath(projectRoot, targetPath string) (string, error) {
 	// Clean the path to resolve any . or .. components
 	cleanPath := filepath.Clean(targetPath)
 
@@ -96,6 +167,58 @@ func SanitizePath(projectRoot, targetPath string) (string, error) {
 	if strings.HasPrefix(relPath, "..") {
 		return "", fmt.Errorf("path outside project root not allowed: %s", targetPath)
 	}
-
 	return absTarget, nil
 }
+
+// mustAbs returns the absolute form of p, which lexicalPath already computed
+// without error.
+func mustAbs(p string) string {
+	abs, _ := filepath.Abs(p)
+	return abs
+}
+
+// resolveLeaf resolves the directories above the absolute path p, then p
+// itself when it is a symlink to something that exists.
+func resolveLeaf(p string) (string, error) {
+	parent := filepath.Dir(p)
+	if parent == p {
+		return p, nil
+	}
+	realParent, err := resolveExisting(parent)
+	if err != nil {
+		return "", err
+	}
+	leaf := filepath.Join(realParent, filepath.Base(p))
+	if info, err := os.Lstat(leaf); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		if resolved, err := filepath.EvalSymlinks(leaf); err == nil {
+			return resolved, nil
+		}
+	}
+	return leaf, nil
+}
+
+// resolveExisting evaluates the symlinks of the longest existing prefix of
+// the absolute path p and appends the rest of p to it. A broken symlink in
+// p is refused: the rest of p would be created through it.
+func resolveExisting(p string) (string, error) {
+	var rest []string
+	for {
+		resolved, err := filepath.EvalSymlinks(p)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, rest...)...), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		if info, lerr := os.Lstat(p); lerr == nil && info.Mode()&os.ModeSymlink != 0 {
+			return "", fmt.Errorf("broken symlink not allowed: %s", p)
+		}
+
+		parent := filepath.Dir(p)
+		if parent == p {
+			return filepath.Join(append([]string{p}, rest...)...), nil
+		}
+		rest = append([]string{filepath.Base(p)}, rest...)
+		p = parent
+	}
+}
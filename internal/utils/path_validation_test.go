@@ -1,7 +1,9 @@
 package utils
 
 import (
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -236,6 +238,52 @@ func TestSanitizePath(t *testing.T) {
 	}
 }
 
+func TestResolveLinkTarget(t *testing.T) {
+	root := t.TempDir()
+
+	tests := []struct {
+		name        string
+		link        string
+		target      string
+		want        string
+		expectError bool
+		errorMsg    string
+	}{
+		{name: "sibling", link: "current", target: "v2", want: "v2"},
+		{name: "parent directory", link: "svc/.golangci.yml", target: "../.golangci.yml", want: ".golangci.yml"},
+		{name: "nested target", link: "docs/logo.png", target: "../assets/img/logo.png", want: "assets/img/logo.png"},
+		{name: "project root", link: "svc/root", target: "..", want: "."},
+		{name: "escape", link: "svc/passwd", target: "../../etc/passwd", expectError: true, errorMsg: "symlink target outside project root not allowed"},
+		{name: "absolute", link: "passwd", target: "/etc/passwd", expectError: true, errorMsg: "absolute symlink targets are not allowed"},
+		{name: "windows absolute", link: "evil", target: "C:\\Windows", expectError: true, errorMsg: "absolute symlink targets are not allowed"},
+		{name: "empty", link: "link", target: " ", expectError: true, errorMsg: "symlink target cannot be empty"},
+		{name: "itself", link: "svc/link", target: "../svc/link", expectError: true, errorMsg: "symlink cannot point to itself"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link := filepath.Join(root, filepath.FromSlash(tt.link))
+			result, err := ResolveLinkTarget(root, link, tt.target)
+
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("expected error for target %q, but got %q", tt.target, result)
+				}
+				if !contains(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error to contain %q, got %q", tt.errorMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error for target %q, but got: %v", tt.target, err)
+			}
+			if want := filepath.Join(root, filepath.FromSlash(tt.want)); result != want {
+				t.Errorf("expected %q, got %q", want, result)
+			}
+		})
+	}
+}
+
 // Helper function to check if a string contains a substring
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) &&
@@ -252,3 +300,83 @@ func indexOfSubstring(s, substr string) int {
 	}
 	return -1
 }
+
+func TestSanitizePath_Symlinks(t *testing.T) {
+	base := t.TempDir()
+	root := filepath.Join(base, "project")
+	outside := filepath.Join(base, "outside")
+	for _, dir := range []string{filepath.Join(root, "v2"), outside} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := map[string]string{
+		"escape":  outside,
+		"current": "v2",
+		"broken":  filepath.Join(outside, "missing"),
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, name)); err != nil {
+			t.Skipf("symlinks not supported: %v", err)
+		}
+	}
+
+	tests := []struct {
+		target   string
+		write    bool // check with SanitizeWritePath
+		errorMsg string
+	}{
+		{target: "current/main.go"},
+		{target: "new/dir/main.go"},
+		{target: "escape", errorMsg: "escapes project root through a symlink"},
+		{target: "escape/new/main.go", errorMsg: "escapes project root through a symlink"},
+		// a symlink node may replace a broken link, nothing may write through it
+		{target: "broken"},
+		{target: "broken", write: true, errorMsg: "broken symlink not allowed"},
+		{target: "broken/main.go", errorMsg: "broken symlink not allowed"},
+		{target: "current/main.go", write: true},
+	}
+	for _, tt := range tests {
+		sanitize := SanitizePath
+		if tt.write {
+			sanitize = SanitizeWritePath
+		}
+		_, err := sanitize(root, filepath.Join(root, filepath.FromSlash(tt.target)))
+		if tt.errorMsg == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tt.target, err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.target, tt.errorMsg, err)
+		}
+	}
+}
+
+func TestLexicalLinkTarget(t *testing.T) {
+	base := t.TempDir()
+	root := filepath.Join(base, "project")
+	outside := filepath.Join(base, "outside")
+	for _, dir := range []string{root, outside} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "shared")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	link := filepath.Join(root, "config")
+	if _, err := ResolveLinkTarget(root, link, "shared/victim"); err == nil {
+		t.Error("ResolveLinkTarget: expected the symlink on disk to be followed")
+	}
+	got, err := LexicalLinkTarget(root, link, "shared/victim")
+	if err != nil {
+		t.Fatalf("LexicalLinkTarget: unexpected error: %v", err)
+	}
+	if want := filepath.Join(root, "shared", "victim"); got != want {
+		t.Errorf("LexicalLinkTarget: expected %q, got %q", want, got)
+	}
+	if _, err := LexicalLinkTarget(root, link, "../outside/victim"); err == nil || !strings.Contains(err.Error(), "symlink target outside project root not allowed") {
+		t.Errorf("LexicalLinkTarget: expected a containment error, got %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Portability is a set of rules that path names must follow to work on the
+// file systems a project targets. Lengths are counted in bytes; zero means no
+// limit.
+type Portability struct {
+	Name string
+
+	MaxComponent int // longest name of a single file or directory
+	MaxPath      int // longest path relative to the project root
+
+	InvalidChars     string // characters not allowed in names
+	ControlChars     bool   // reject control characters (NUL is always rejected)
+	ReservedNames    bool   // reject Windows device names (CON, NUL, COM1, ...), with or without extension
+	TrailingDotSpace bool   // reject names ending with a dot or a space
+	PortableCharset  bool   // allow only letters, digits, '.', '_' and '-', and no leading '-'
+}
+
+// Portability profiles.
+var (
+	// POSIX accepts what Linux and macOS file systems do.
+	POSIX = &Portability{
+		Name:         "posix",
+		MaxComponent: 255,
+		MaxPath:      4095,
+	}
+
+	// Windows follows the Win32 naming rules and the MAX_PATH limit.
+	Windows = &Portability{
+		Name:             "windows",
+		MaxComponent:     255,
+		MaxPath:          259,
+		InvalidChars:     `<>:"|?*`,
+		ControlChars:     true,
+		ReservedNames:    true,
+		TrailingDotSpace: true,
+	}
+
+	// Portable accepts names that work on both, and is the default. The
+	// Windows rules are the stricter ones on every point, so it is an alias
+	// of Windows under its own name.
+	Portable = alias(Windows, "portable")
+
+	// Strict keeps to the POSIX portable filename character set and to the
+	// name lengths of ustar archives.
+	Strict = &Portability{
+		Name:             "strict",
+		MaxComponent:     100,
+		MaxPath:          255,
+		InvalidChars:     `<>:"|?*`,
+		ControlChars:     true,
+		ReservedNames:    true,
+		TrailingDotSpace: true,
+		PortableCharset:  true,
+	}
+)
+
+// alias returns a copy of p called name.
+func alias(p *Portability, name string) *Portability {
+	a := *p
+	a.Name = name
+	return &a
+}
+
+// PortabilityProfiles lists the profile names accepted by PortabilityProfile.
+var PortabilityProfiles = []string{POSIX.Name, Windows.Name, Portable.Name, Strict.Name}
+
+// PortabilityProfile returns the profile called name, or Portable for "".
+func PortabilityProfile(name string) (*Portability, error) {
+	for _, p := range []*Portability{POSIX, Windows, Portable, Strict} {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	if name == "" {
+		return Portable, nil
+	}
+	return nil, fmt.Errorf("unknown portability profile %q (available: %s)", name, strings.Join(PortabilityProfiles, ", "))
+}
+
+// windowsDevices are the names Windows reserves for devices, in any case and
+// with any extension.
+var windowsDevices = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true, "CONIN$": true, "CONOUT$": true,
+	"COM0": true, "COM1": true, "COM2": true, "COM3": true, "COM4": true,
+	"COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT0": true, "LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true,
+	"LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// ValidateName checks a single path component: the safety rules shared by
+// every profile (see ValidatePath), then the rules of p.
+func (p *Portability) ValidateName(name string) error {
+	if err := validateComponent(name); err != nil {
+		return err
+	}
+
+	if strings.ContainsRune(name, 0) {
+		return fmt.Errorf("NUL character in path name: %q", name)
+	}
+	if p.ControlChars {
+		for _, r := range name {
+			if unicode.IsControl(r) {
+				return fmt.Errorf("control character %U in path name: %q", r, name)
+			}
+		}
+	}
+
+	for _, char := range p.InvalidChars {
+		if strings.ContainsRune(name, char) {
+			return fmt.Errorf("invalid character '%c' in path name: %s", char, name)
+		}
+	}
+
+	if p.PortableCharset {
+		for _, r := range name {
+			if !isPortableChar(r) {
+				return fmt.Errorf("character %q in path name is outside the portable set [A-Za-z0-9._-]: %s", r, name)
+			}
+		}
+		if strings.HasPrefix(name, "-") {
+			return fmt.Errorf("path name cannot start with '-': %s", name)
+		}
+	}
+
+	if p.TrailingDotSpace && (strings.HasSuffix(name, ".") || strings.HasSuffix(name, " ")) {
+		return fmt.Errorf("path name cannot end with a dot or a space: %q", name)
+	}
+
+	if p.ReservedNames {
+		base, _, _ := strings.Cut(strings.TrimSpace(name), ".")
+		if windowsDevices[strings.ToUpper(strings.TrimRight(base, " "))] {
+			return fmt.Errorf("reserved filename not allowed: %s", name)
+		}
+	}
+
+	if p.MaxComponent > 0 && len(name) > p.MaxComponent {
+		return fmt.Errorf("path name is %d bytes long, the %s limit is %d: %s", len(name), p.Name, p.MaxComponent, name)
+	}
+	return nil
+}
+
+// ValidateLength checks the length of a slash-separated path relative to the
+// output directory, so a monorepo project's output is part of it.
+func (p *Portability) ValidateLength(relPath string) error {
+	relPath = strings.TrimPrefix(relPath, "/")
+	if p.MaxPath > 0 && len(relPath) > p.MaxPath {
+		return fmt.Errorf("path is %d bytes long, the %s limit is %d: %s", len(relPath), p.Name, p.MaxPath, relPath)
+	}
+	return nil
+}
+
+func isPortableChar(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '_' || r == '-'
+}
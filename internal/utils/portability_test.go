@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPortability_ValidateName(t *testing.T) {
+	long := strings.Repeat("a", 101)
+
+	tests := []struct {
+		name  string
+		input string
+		// expected error fragment per profile; "" means the name is accepted
+		posix, windows, portable, strict string
+	}{
+		{name: "plain", input: "main.go"},
+		{name: "traversal", input: "..", posix: "path traversal", windows: "path traversal", portable: "path traversal", strict: "path traversal"},
+		{name: "separator", input: "a/b", posix: "path separators", windows: "path separators", portable: "path separators", strict: "path separators"},
+		{name: "NUL", input: "a\x00b", posix: "NUL character", windows: "NUL character", portable: "NUL character", strict: "NUL character"},
+		{name: "control character", input: "a\tb", windows: "control character U+0009", portable: "control character U+0009", strict: "control character U+0009"},
+		{name: "windows character", input: "what?.md", windows: "invalid character '?'", portable: "invalid character '?'", strict: "invalid character '?'"},
+		{name: "device name", input: "NUL", windows: "reserved filename", portable: "reserved filename", strict: "reserved filename"},
+		{name: "device name with extension", input: "com1.txt", windows: "reserved filename", portable: "reserved filename", strict: "reserved filename"},
+		{name: "device-like name", input: "console.log"},
+		{name: "trailing dot", input: "notes.", windows: "end with a dot or a space", portable: "end with a dot or a space", strict: "end with a dot or a space"},
+		{name: "trailing space", input: "notes ", windows: "end with a dot or a space", portable: "end with a dot or a space", strict: "outside the portable set"},
+		{name: "space", input: "my notes.md", strict: "outside the portable set"},
+		{name: "non-ASCII", input: "caf\u00e9.md", strict: "outside the portable set"},
+		{name: "leading hyphen", input: "-rf", strict: "cannot start with '-'"},
+		{name: "101 bytes", input: long, strict: "the strict limit is 100"},
+		{name: "256 bytes", input: strings.Repeat("a", 256), posix: "the posix limit is 255", windows: "the windows limit is 255", portable: "the portable limit is 255", strict: "the strict limit is 100"},
+	}
+
+	for _, tt := range tests {
+		for _, c := range []struct {
+			profile *Portability
+			want    string
+		}{{POSIX, tt.posix}, {Windows, tt.windows}, {Portable, tt.portable}, {Strict, tt.strict}} {
+			t.Run(tt.name+"/"+c.profile.Name, func(t *testing.T) {
+				err := c.profile.ValidateName(tt.input)
+				if c.want == "" {
+					if err != nil {
+						t.Fatalf("expected %q to be accepted, got: %v", tt.input, err)
+					}
+					return
+				}
+				if err == nil || !strings.Contains(err.Error(), c.want) {
+					t.Fatalf("expected error containing %q, got: %v", c.want, err)
+				}
+			})
+		}
+	}
+}
+
+func TestPortability_ValidateLength(t *testing.T) {
+	path := "/" + strings.Repeat("abcdefghi/", 26) // 260 bytes without the leading slash
+	if err := POSIX.ValidateLength(path); err != nil {
+		t.Errorf("posix: unexpected error: %v", err)
+	}
+	for _, p := range []*Portability{Windows, Portable, Strict} {
+		if err := p.ValidateLength(path); err == nil || !strings.Contains(err.Error(), "path is 260 bytes long") {
+			t.Errorf("%s: expected a length error, got: %v", p.Name, err)
+		}
+	}
+}
+
+func TestPortability_PortableIsWindows(t *testing.T) {
+	alias := *Portable
+	alias.Name = Windows.Name
+	if alias != *Windows {
+		t.Errorf("expected portable to follow the windows rules, got %+v", *Portable)
+	}
+}
+
+func TestPortabilityProfile(t *testing.T) {
+	if p, err := PortabilityProfile(""); err != nil || p != Portable {
+		t.Errorf("empty name: got %v, %v; want the portable profile", p, err)
+	}
+	for _, name := range PortabilityProfiles {
+		if p, err := PortabilityProfile(name); err != nil || p.Name != name {
+			t.Errorf("%s: got %v, %v", name, p, err)
+		}
+	}
+	if _, err := PortabilityProfile("dos"); err == nil || !strings.Contains(err.Error(), "unknown portability profile") {
+		t.Errorf("expected an unknown profile error, got: %v", err)
+	}
+}